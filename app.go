@@ -13,6 +13,7 @@ package gimlet
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -44,16 +45,19 @@ type APIApp struct {
 	port           int
 	address        string
 	routes         []*APIRoute
+	versioning     VersioningOptions
+	problemDetails bool
 
 	routerImpl RouterImplementation
-	router     *mux.Router
-	mux        *chi.Mux
+	adapter    RouterAdapter
 	middleware []interface{}
 	wrappers   []interface{}
 }
 
 // RouterImplementation describes the http Routing infrastructure the
-// Application will use to configure routes.
+// Application will use to configure routes. In addition to the
+// built-in implementations, you can use RegisterRouter to add
+// implementations backed by other routers.
 type RouterImplementation int
 
 const (
@@ -64,37 +68,40 @@ const (
 	// routing functions.
 	//
 	// RouterImplChi uses the go-chi/chi routing infrastructure.
+	//
+	// RouterImplStdlib uses the standard library's http.ServeMux,
+	// which supports method and wildcard patterns as of Go 1.22.
 	RouterImplUndefined = iota
 	RouterImplGorilla
 	RouterImplChi
+	RouterImplStdlib
 )
 
 // String implements fmt.Stringer for the RouterImplementation.
 func (r RouterImplementation) String() string {
-	switch r {
-	case RouterImplGorilla:
-		return "gorilla"
-	case RouterImplChi:
-		return "chi"
-	case RouterImplUndefined:
+	if r == RouterImplUndefined {
 		return "<undefined>"
-	default:
-		return "<invalid-router>"
 	}
 
+	if name, _, ok := r.lookup(); ok {
+		return name
+	}
+
+	return "<invalid-router>"
 }
 
 // Validate returns an error if the router implementation is invalid
 // or not specified.
 func (r RouterImplementation) Validate() error {
-	switch r {
-	case RouterImplChi, RouterImplGorilla:
-		return nil
-	case RouterImplUndefined:
+	if r == RouterImplUndefined {
 		return errors.New("unspecified router implementation")
-	default:
+	}
+
+	if _, _, ok := r.lookup(); !ok {
 		return errors.Errorf("%d is not a valid router [%s]", r, r.String())
 	}
+
+	return nil
 }
 
 // NewApp returns a pointer to an application instance. These
@@ -120,10 +127,15 @@ func (a *APIApp) Router() (*mux.Router, error) {
 		return nil, errors.Errorf("gorilla router is not configured [%s]", a.routerImpl)
 	}
 
-	if a.isResolved {
-		return a.router, nil
+	if !a.isResolved {
+		return nil, errors.New("application is not resolved")
 	}
-	return nil, errors.New("application is not resolved")
+
+	adapter, ok := a.adapter.(*gorillaAdapter)
+	if !ok {
+		return nil, errors.Errorf("application has a %T router, not a gorilla router", a.adapter)
+	}
+	return adapter.router, nil
 }
 
 // Mux is a getter for an APIApp's chi Muxer object. If the
@@ -134,10 +146,39 @@ func (a *APIApp) Mux() (*chi.Mux, error) {
 		return nil, errors.New("chi router is not configured")
 	}
 
-	if a.isResolved {
-		return a.mux, nil
+	if !a.isResolved {
+		return nil, errors.New("application is not resolved")
+	}
+
+	adapter, ok := a.adapter.(*chiAdapter)
+	if !ok {
+		return nil, errors.Errorf("application has a %T router, not a chi router", a.adapter)
+	}
+
+	mux, ok := adapter.router.(*chi.Mux)
+	if !ok {
+		return nil, errors.Errorf("application has a %T chi router, not a chi.Mux", adapter.router)
+	}
+	return mux, nil
+}
+
+// ServeMux is a getter for an APIApp's standard library
+// http.ServeMux. If the application isn't resolved or uses a
+// different routing stack, then this is an error.
+func (a *APIApp) ServeMux() (*http.ServeMux, error) {
+	if a.routerImpl != RouterImplStdlib {
+		return nil, errors.New("stdlib router is not configured")
+	}
+
+	if !a.isResolved {
+		return nil, errors.New("application is not resolved")
+	}
+
+	adapter, ok := a.adapter.(*stdlibAdapter)
+	if !ok {
+		return nil, errors.Errorf("application has a %T router, not a stdlib router", a.adapter)
 	}
-	return nil, errors.New("application is not resolved")
+	return adapter.mux, nil
 }
 
 // AddMiddleware adds a negroni handler as middleware to the end of
@@ -213,7 +254,9 @@ func (a *APIApp) ResetMiddleware() {
 
 // SetRouter allows you to configure which underlying router
 // infrastructure the Application will use. It is an error to merge
-// two applications with different routing implementations.
+// two applications with different routing implementations. Use
+// RegisterRouter to produce RouterImplementation values for custom
+// RouterAdapter implementations.
 func (a *APIApp) SetRouter(r RouterImplementation) *APIApp {
 	a.routerImpl = r
 	return a
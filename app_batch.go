@@ -0,0 +1,274 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/go-chi/chi"
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip/message"
+)
+
+const defaultBatchMaxRequests = 100
+
+// BatchRequest is one of the requests in the body of a batch
+// request. The path is the path that clients would request directly,
+// including the application prefix, version and query, and the body
+// is a JSON document.
+type BatchRequest struct {
+	Method  string          `bson:"method" json:"method" yaml:"method"`
+	Path    string          `bson:"path" json:"path" yaml:"path"`
+	Headers http.Header     `bson:"headers,omitempty" json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    json.RawMessage `bson:"body,omitempty" json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// BatchResponse is the response to one of the requests in a batch
+// request. JSON bodies are embedded in the batch response as
+// documents, and other bodies as strings.
+type BatchResponse struct {
+	Status  int             `bson:"status" json:"status" yaml:"status"`
+	Headers http.Header     `bson:"headers,omitempty" json:"headers,omitempty" yaml:"headers,omitempty"`
+	Body    json.RawMessage `bson:"body,omitempty" json:"body,omitempty" yaml:"body,omitempty"`
+}
+
+// BatchOptions configures the batch route.
+type BatchOptions struct {
+	// MaxRequests is the largest number of requests in a batch,
+	// and defaults to 100.
+	MaxRequests int
+	// Concurrency is the number of requests in a batch that the
+	// route handles at once. By default, the route handles the
+	// requests in order, one at a time.
+	Concurrency int
+}
+
+// AddBatchRoute registers a POST route that lets clients combine
+// several requests in one round trip. The body of batch requests is a
+// JSON array of BatchRequest documents, and the route responds with a
+// JSON array of BatchResponse documents, in the same order.
+//
+// The route dispatches each request through the application's router
+// and the wrappers of the matching route, so authorization wrappers
+// apply to each request. Application middleware only runs for the
+// batch request itself: requests in the batch have the user, logger
+// and request ID of the batch request, and inherit its Authorization
+// and Cookie headers unless they specify their own. Batches cannot
+// contain batch requests.
+func (a *APIApp) AddBatchRoute(route string, opts BatchOptions) *APIRoute {
+	if opts.MaxRequests <= 0 {
+		opts.MaxRequests = defaultBatchMaxRequests
+	}
+
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = 1
+	}
+
+	return a.AddRoute(route).Post().Summary("Batch requests").
+		RequestType([]BatchRequest{}).ResponseType(http.StatusOK, []BatchResponse{}).
+		Handler(func(rw http.ResponseWriter, r *http.Request) {
+			ctx := r.Context()
+			if inBatch(ctx) {
+				writeResponder(ctx, rw, MakeJSONErrorResponder(ErrorResponse{
+					StatusCode: http.StatusBadRequest,
+					Message:    "batch requests cannot be nested",
+				}))
+				return
+			}
+
+			requests := []BatchRequest{}
+			if err := GetBody(r, &requests); err != nil {
+				writeResponder(ctx, rw, MakeJSONErrorResponder(err))
+				return
+			}
+
+			if len(requests) > opts.MaxRequests {
+				writeResponder(ctx, rw, MakeJSONErrorResponder(ErrorResponse{
+					StatusCode: http.StatusRequestEntityTooLarge,
+					Message:    fmt.Sprintf("batch has %d requests, more than the limit of %d", len(requests), opts.MaxRequests),
+				}))
+				return
+			}
+
+			router := getRouterAdapter(ctx)
+			if router == nil {
+				writeResponder(ctx, rw, MakeJSONInternalErrorResponder(errors.New("batch route is not served by a gimlet router")))
+				return
+			}
+
+			handler, err := router.Handler(nil)
+			if err != nil {
+				writeResponder(ctx, rw, MakeJSONInternalErrorResponder(errors.Wrap(err, "problem resolving router")))
+				return
+			}
+
+			WriteJSON(rw, dispatchBatch(r, handler, requests, opts.Concurrency))
+		})
+}
+
+func dispatchBatch(r *http.Request, handler http.Handler, requests []BatchRequest, concurrency int) []BatchResponse {
+	out := make([]BatchResponse, len(requests))
+	sem := make(chan struct{}, concurrency)
+	wg := &sync.WaitGroup{}
+
+	for idx := range requests {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			out[idx] = dispatchBatchRequest(r, handler, requests[idx])
+		}()
+	}
+
+	wg.Wait()
+	return out
+}
+
+func dispatchBatchRequest(parent *http.Request, handler http.Handler, br BatchRequest) (out BatchResponse) {
+	req, err := newBatchRequest(parent, br)
+	if err != nil {
+		return newBatchResponse(MakeJSONErrorResponder(err))
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err := errors.Errorf("batch request panicked: %v", p)
+			GetLogger(req.Context()).Error(message.WrapError(err, message.Fields{
+				"method": br.Method,
+				"path":   br.Path,
+			}))
+			out = newBatchResponse(MakeJSONInternalErrorResponder(err))
+		}
+	}()
+
+	buf := &bufferedResponse{header: http.Header{}}
+	handler.ServeHTTP(buf, req)
+	if buf.status == 0 {
+		buf.WriteHeader(http.StatusOK)
+	}
+
+	return BatchResponse{
+		Status:  buf.status,
+		Headers: buf.sent,
+		Body:    encodeBatchBody(buf.sent.Get("Content-Type"), buf.body.Bytes()),
+	}
+}
+
+func newBatchRequest(parent *http.Request, br BatchRequest) (*http.Request, error) {
+	method := strings.ToUpper(br.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	if !strings.HasPrefix(br.Path, "/") {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("batch request path '%s' must begin with '/'", br.Path),
+		}
+	}
+
+	u, err := url.ParseRequestURI(br.Path)
+	if err != nil {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrapf(err, "batch request path '%s' is not valid", br.Path).Error(),
+		}
+	}
+
+	var body []byte
+	if len(br.Body) > 0 && !bytes.Equal(br.Body, []byte("null")) {
+		body = br.Body
+	}
+
+	req, err := http.NewRequestWithContext(withBatch(parent.Context()), method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    errors.Wrap(err, "batch request is not valid").Error(),
+		}
+	}
+
+	req.RequestURI = u.RequestURI()
+	req.Host = parent.Host
+	req.RemoteAddr = parent.RemoteAddr
+	req.TLS = parent.TLS
+	for key, values := range br.Headers {
+		req.Header[http.CanonicalHeaderKey(key)] = values
+	}
+
+	for _, key := range []string{"Authorization", "Cookie"} {
+		if _, ok := req.Header[key]; !ok && parent.Header.Get(key) != "" {
+			req.Header[key] = parent.Header.Values(key)
+		}
+	}
+
+	if body != nil && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", JSON.ContentType())
+	}
+
+	return req, nil
+}
+
+func newBatchResponse(resp Responder) BatchResponse {
+	buf := &bufferedResponse{header: http.Header{}}
+	WriteResponse(buf, resp)
+
+	return BatchResponse{
+		Status:  buf.status,
+		Headers: buf.sent,
+		Body:    encodeBatchBody(buf.sent.Get("Content-Type"), buf.body.Bytes()),
+	}
+}
+
+// encodeBatchBody embeds JSON response bodies as documents, and
+// other bodies as strings.
+func encodeBatchBody(contentType string, body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	if (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && json.Valid(body) {
+		return body
+	}
+
+	out, _ := json.Marshal(string(body))
+	return out
+}
+
+// withBatch marks the context of requests in a batch, and hides the
+// values that gimlet and the router attach to the context for the
+// batch route, so that each request in the batch has the values of
+// its own route. Chi reuses the routing context of requests that
+// already have one, which would route requests in the batch with
+// the method of the batch request.
+func withBatch(ctx context.Context) context.Context {
+	return batchContext{Context: ctx}
+}
+
+func inBatch(ctx context.Context) bool {
+	isBatch, _ := ctx.Value(batchKey).(bool)
+	return isBatch
+}
+
+type batchContext struct {
+	context.Context
+}
+
+func (c batchContext) Value(key interface{}) interface{} {
+	switch key {
+	case batchKey:
+		return true
+	case routerAdapterKey, urlResolverKey, versionKey, maxRequestSizeKey, etagModeKey, problemDetailsKey, chi.RouteCtxKey:
+		return nil
+	default:
+		return c.Context.Value(key)
+	}
+}
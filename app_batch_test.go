@@ -0,0 +1,196 @@
+package gimlet
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBatchRoute(t *testing.T) {
+	for name, impl := range map[string]RouterImplementation{
+		"Gorilla": RouterImplGorilla,
+		"Chi":     RouterImplChi,
+		"Stdlib":  RouterImplStdlib,
+	} {
+		t.Run(name, func(t *testing.T) { testBatchRoute(t, impl) })
+	}
+}
+
+func testBatchRoute(t *testing.T, impl RouterImplementation) {
+	var inflight, peak atomic.Int64
+
+	newApp := func(opts BatchOptions) http.Handler {
+		app := NewApp().SetRouter(impl)
+		app.SetPrefix("api")
+		app.AddMiddleware(NewAuthenticationHandler(&MockAuthenticator{CheckAuthenticatedState: map[string]bool{"alice": true}}, &MockUserManager{}))
+		app.AddMiddlewareFunc(func(next http.HandlerFunc) http.HandlerFunc {
+			return func(rw http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "alice" {
+					r = r.WithContext(AttachUser(r.Context(), &MockUser{ID: "alice"}))
+				}
+				next(rw, r)
+			}
+		})
+
+		app.AddRoute("/items/{id}").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			time.Sleep(5 * time.Millisecond)
+
+			rw.Header().Set("X-Item", GetParam(r, "id"))
+			WriteJSON(rw, map[string]string{"id": GetParam(r, "id"), "q": r.URL.Query().Get("q")})
+		})
+		app.AddRoute("/items").Version(1).Post().Wrap(NewRequireAuthHandler()).Handler(func(rw http.ResponseWriter, r *http.Request) {
+			doc := map[string]interface{}{}
+			if err := GetBody(r, &doc); err != nil {
+				WriteResponse(rw, MakeJSONErrorResponder(err))
+				return
+			}
+			doc["user"] = GetUser(r.Context()).Username()
+			WriteJSONResponse(rw, http.StatusCreated, doc)
+		})
+		app.AddRoute("/text").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			WriteText(rw, "hello")
+		})
+		app.AddRoute("/panic").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			panic("boom")
+		})
+		app.AddBatchRoute("/batch", opts).Version(1)
+
+		handler, err := app.Handler()
+		require.NoError(t, err)
+		return handler
+	}
+
+	batch := func(handler http.Handler, auth string, body string) (*httptest.ResponseRecorder, []BatchResponse) {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/batch", strings.NewReader(body))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+
+		out := []BatchResponse{}
+		if rw.Code == http.StatusOK {
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+		}
+		return rw, out
+	}
+
+	t.Run("Dispatch", func(t *testing.T) {
+		handler := newApp(BatchOptions{})
+		rw, out := batch(handler, "alice", `[
+			{"method": "get", "path": "/api/v1/items/1?q=a"},
+			{"method": "POST", "path": "/api/v1/items", "body": {"name": "widget"}},
+			{"path": "/api/v1/text"},
+			{"path": "/api/v1/missing"}
+		]`)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Len(t, out, 4)
+
+		assert.Equal(t, http.StatusOK, out[0].Status)
+		assert.Equal(t, "1", out[0].Headers.Get("X-Item"))
+		assert.JSONEq(t, `{"id": "1", "q": "a"}`, string(out[0].Body))
+
+		assert.Equal(t, http.StatusCreated, out[1].Status)
+		assert.JSONEq(t, `{"name": "widget", "user": "alice"}`, string(out[1].Body))
+
+		assert.Equal(t, http.StatusOK, out[2].Status)
+		assert.Equal(t, `"hello"`, string(out[2].Body))
+
+		assert.Equal(t, http.StatusNotFound, out[3].Status)
+	})
+	t.Run("AuthWrappers", func(t *testing.T) {
+		handler := newApp(BatchOptions{})
+		_, out := batch(handler, "", `[{"method": "POST", "path": "/api/v1/items", "body": {}}]`)
+		require.Len(t, out, 1)
+		assert.Equal(t, http.StatusUnauthorized, out[0].Status)
+
+		_, out = batch(handler, "", `[{"method": "POST", "path": "/api/v1/items", "headers": {"Authorization": ["alice"]}, "body": {}}]`)
+		require.Len(t, out, 1)
+		assert.Equal(t, http.StatusUnauthorized, out[0].Status, "application middleware does not run for requests in a batch")
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		handler := newApp(BatchOptions{MaxRequests: 2})
+		_, out := batch(handler, "", `[
+			{"path": "items"},
+			{"path": "/api/v1/batch", "method": "POST", "body": []}
+		]`)
+		require.Len(t, out, 2)
+		assert.Equal(t, http.StatusBadRequest, out[0].Status)
+		assert.Contains(t, string(out[0].Body), "must begin with")
+		assert.Equal(t, http.StatusBadRequest, out[1].Status)
+		assert.Contains(t, string(out[1].Body), "cannot be nested")
+
+		rw, _ := batch(handler, "", `[{}, {}, {}]`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+		rw, _ = batch(handler, "", `{"path": "/api/v1/text"}`)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+	t.Run("Panic", func(t *testing.T) {
+		handler := newApp(BatchOptions{Concurrency: 2})
+		_, out := batch(handler, "", `[{"path": "/api/v1/panic"}, {"path": "/api/v1/text"}]`)
+		require.Len(t, out, 2)
+		assert.Equal(t, http.StatusInternalServerError, out[0].Status)
+		assert.Equal(t, http.StatusOK, out[1].Status)
+	})
+	t.Run("MergedApplications", func(t *testing.T) {
+		app := NewApp().SetRouter(impl)
+		app.SetPrefix("api")
+		app.AddRoute("/hello/{name}").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			WriteText(rw, "hello "+GetParam(r, "name"))
+		})
+		app.AddBatchRoute("/batch", BatchOptions{}).Version(1)
+
+		other := NewApp().SetRouter(impl)
+		other.AddRoute("/other").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			WriteText(rw, "other")
+		})
+
+		handler, err := MergeApplications(app, other)
+		require.NoError(t, err)
+
+		rw, out := batch(handler, "", `[{"path": "/api/v1/hello/world"}, {"path": "/api/v1/missing"}]`)
+		require.Equal(t, http.StatusOK, rw.Code)
+		require.Len(t, out, 2)
+		assert.Equal(t, http.StatusOK, out[0].Status)
+		assert.Equal(t, `"hello world"`, string(out[0].Body))
+		assert.Equal(t, http.StatusNotFound, out[1].Status)
+	})
+	t.Run("Concurrency", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		body.WriteString("[")
+		for i := 0; i < 12; i++ {
+			if i > 0 {
+				body.WriteString(",")
+			}
+			body.WriteString(`{"path": "/api/v1/items/` + string(rune('a'+i)) + `"}`)
+		}
+		body.WriteString("]")
+
+		for concurrency, limit := range map[int]int64{0: 1, 4: 4} {
+			peak.Store(0)
+			_, out := batch(newApp(BatchOptions{Concurrency: concurrency}), "", body.String())
+			require.Len(t, out, 12)
+			for i, resp := range out {
+				assert.Equal(t, string(rune('a'+i)), resp.Headers.Get("X-Item"), "responses are in the order of the requests")
+			}
+			assert.LessOrEqual(t, peak.Load(), limit)
+			assert.Positive(t, peak.Load())
+		}
+	})
+}
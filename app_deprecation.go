@@ -0,0 +1,143 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/tychoish/grip"
+	"github.com/tychoish/grip/message"
+)
+
+// latestVersionAlias is the version segment for routes that are also
+// available as the latest version of the route.
+const latestVersionAlias = "latest"
+
+// RouteDeprecation describes a deprecated route. Requests to
+// deprecated versions of the route receive Deprecation, Sunset, and
+// Link headers, and gimlet logs each request using the request's
+// logger.
+type RouteDeprecation struct {
+	// At is the time the route was (or will be) deprecated. If
+	// unset, the Deprecation header is "true".
+	At time.Time
+
+	// Sunset is the time after which the route will be
+	// unavailable, and is optional.
+	Sunset time.Time
+
+	// Successor is the URL of the route that replaces the
+	// deprecated route, and is optional.
+	Successor string
+
+	// Before limits the deprecation to versions of the route
+	// lower than Before, for routes that are available at more
+	// than one version. If zero, all versions are deprecated.
+	Before int
+}
+
+// Versions makes the route available at every version from the first
+// to the last, inclusively, without registering the route more than
+// once.
+func (r *APIRoute) Versions(first, last int) *APIRoute {
+	if first < 0 || last < first {
+		grip.Warningf("%d-%d is not a valid version range", first, last)
+		return r
+	}
+
+	r.version = first
+	r.lastVersion = last
+	return r
+}
+
+// Latest makes the route available with "latest" in place of the
+// version segment (e.g. "/latest/foo"), in addition to its
+// versioned paths. Applications that negotiate versions with a
+// header also accept "latest" as a version, which selects the
+// highest version of the route.
+func (r *APIRoute) Latest() *APIRoute {
+	r.latest = true
+	return r
+}
+
+// Deprecated marks the route as deprecated.
+func (r *APIRoute) Deprecated(d RouteDeprecation) *APIRoute {
+	r.deprecation = &d
+	return r
+}
+
+func (r *APIRoute) isDeprecated(version int) bool {
+	if r.deprecation == nil {
+		return false
+	}
+
+	return r.deprecation.Before <= 0 || (version >= 0 && version < r.deprecation.Before)
+}
+
+// expand returns a copy of the route for each version that it
+// serves, and a copy for the latest alias, if configured. Routes
+// with a single version and no alias return only themselves.
+func (r *APIRoute) expand() []*APIRoute {
+	if r.version < 0 || (r.lastVersion <= r.version && !r.latest) {
+		return []*APIRoute{r}
+	}
+
+	last := r.version
+	if r.lastVersion > last {
+		last = r.lastVersion
+	}
+
+	out := []*APIRoute{}
+	for v := r.version; v <= last; v++ {
+		route := *r
+		route.version = v
+		route.lastVersion = 0
+		route.latest = false
+		out = append(out, &route)
+	}
+
+	if r.latest {
+		route := *out[len(out)-1]
+		route.latestAlias = true
+		out = append(out, &route)
+	}
+
+	return out
+}
+
+// withDeprecation adds the deprecation headers to responses, and
+// logs the request.
+func withDeprecation(route *APIRoute, next http.Handler) http.Handler {
+	d := route.deprecation
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if d.At.IsZero() {
+			rw.Header().Set("Deprecation", "true")
+		} else {
+			rw.Header().Set("Deprecation", fmt.Sprintf("@%d", d.At.Unix()))
+		}
+
+		if !d.Sunset.IsZero() {
+			rw.Header().Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+
+		if d.Successor != "" {
+			rw.Header().Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, d.Successor))
+		}
+
+		m := message.Fields{
+			"message": "deprecated route",
+			"method":  r.Method,
+			"path":    r.URL.Path,
+			"route":   route.route,
+			"version": route.version,
+			"request": GetRequestID(r.Context()),
+		}
+		if !d.Sunset.IsZero() {
+			m["sunset"] = d.Sunset
+		}
+		GetLogger(r.Context()).Warning(m)
+
+		next.ServeHTTP(rw, r)
+	})
+}
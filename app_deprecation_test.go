@@ -0,0 +1,155 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tychoish/grip"
+	"github.com/tychoish/grip/send"
+)
+
+func TestRouteVersionRanges(t *testing.T) {
+	versioned := func(rw http.ResponseWriter, r *http.Request) {
+		v, _ := GetVersion(r.Context())
+		WriteText(rw, fmt.Sprint(v))
+	}
+
+	t.Run("Expand", func(t *testing.T) {
+		r := &APIRoute{route: "/foo", version: -1}
+		assert.Len(t, r.expand(), 1)
+
+		r.Version(1)
+		assert.Equal(t, []*APIRoute{r}, r.expand())
+
+		r.Versions(1, 3)
+		versions := r.expand()
+		require.Len(t, versions, 3)
+		for idx, v := range versions {
+			assert.Equal(t, idx+1, v.version)
+			assert.False(t, v.latestAlias)
+		}
+
+		r.Latest()
+		versions = r.expand()
+		require.Len(t, versions, 4)
+		assert.True(t, versions[3].latestAlias)
+		assert.Equal(t, 3, versions[3].version)
+
+		r.Versions(3, 1)
+		assert.Equal(t, 1, r.version)
+		r.Version(2)
+		assert.Len(t, r.expand(), 2)
+	})
+	for name, impl := range map[string]RouterImplementation{
+		"Gorilla": RouterImplGorilla,
+		"Chi":     RouterImplChi,
+		"Stdlib":  RouterImplStdlib,
+	} {
+		t.Run(name, func(t *testing.T) {
+			app := NewApp()
+			app.SetRouter(impl)
+			app.AddRoute("/foo").Versions(1, 3).Latest().Get().Handler(versioned).Name("foo")
+			app.AddRoute("/foo").Version(4).Get().Handler(versioned)
+
+			h, err := app.Handler()
+			require.NoError(t, err)
+
+			for path, expected := range map[string]string{
+				"/v1/foo":     "1",
+				"/v2/foo":     "2",
+				"/v3/foo":     "3",
+				"/v4/foo":     "4",
+				"/latest/foo": "3",
+			} {
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+				assert.Equal(t, http.StatusOK, rw.Code, path)
+				assert.Equal(t, expected, rw.Body.String(), path)
+			}
+
+			path, err := app.URL("foo")
+			require.NoError(t, err)
+			assert.Equal(t, "/v3/foo", path)
+
+			assert.Len(t, app.Routes(), 5)
+		})
+	}
+	t.Run("Header", func(t *testing.T) {
+		app := NewApp()
+		require.NoError(t, app.SetVersioning(VersioningOptions{Strategy: VersionByHeader, DefaultVersion: 1}))
+		app.AddRoute("/foo").Versions(1, 2).Latest().Get().Handler(versioned)
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		for version, expected := range map[string]string{"1": "1", "2": "2", "latest": "2"} {
+			req := httptest.NewRequest(http.MethodGet, "/foo", nil)
+			req.Header.Set("API-Version", version)
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, req)
+			assert.Equal(t, expected, rw.Body.String())
+		}
+	})
+}
+
+func TestRouteDeprecation(t *testing.T) {
+	sender := send.NewInternal(128)
+	sender.SetPriority(grip.Sender().Priority())
+
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+	deprecated := time.Date(2029, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	app := NewApp()
+	app.AddMiddleware(&appLogging{grip.NewLogger(sender)})
+	app.AddRoute("/foo").Versions(1, 3).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {}).
+		Deprecated(RouteDeprecation{At: deprecated, Sunset: sunset, Successor: "/v3/foo", Before: 3})
+	app.AddRoute("/bar").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {}).
+		Deprecated(RouteDeprecation{})
+
+	h, err := app.Handler()
+	require.NoError(t, err)
+
+	rw := httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v2/foo", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, fmt.Sprintf("@%d", deprecated.Unix()), rw.Header().Get("Deprecation"))
+	assert.Equal(t, "Tue, 01 Jan 2030 00:00:00 GMT", rw.Header().Get("Sunset"))
+	assert.Equal(t, `</v3/foo>; rel="successor-version"`, rw.Header().Get("Link"))
+
+	var logged bool
+	for sender.HasMessage() {
+		if strings.Contains(sender.GetMessage().Message.String(), "deprecated route") {
+			logged = true
+		}
+	}
+	assert.True(t, logged)
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v3/foo", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Header().Get("Deprecation"))
+	assert.Empty(t, rw.Header().Get("Sunset"))
+
+	rw = httptest.NewRecorder()
+	h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/bar", nil))
+	assert.Equal(t, "true", rw.Header().Get("Deprecation"))
+	assert.Empty(t, rw.Header().Get("Sunset"))
+	assert.Empty(t, rw.Header().Get("Link"))
+
+	routes := app.Routes()
+	require.Len(t, routes, 4)
+	assert.True(t, routes[0].Deprecated)
+	assert.False(t, routes[2].Deprecated)
+	assert.True(t, routes[3].Deprecated)
+
+	doc, err := app.OpenAPI(OpenAPIInfo{})
+	require.NoError(t, err)
+	assert.True(t, doc.Paths["/v1/foo"]["get"].Deprecated)
+	assert.False(t, doc.Paths["/v3/foo"]["get"].Deprecated)
+}
@@ -0,0 +1,161 @@
+package gimlet
+
+import (
+	"strings"
+
+	"github.com/tychoish/grip"
+)
+
+// RouteGroup defines settings shared by a set of routes. Use
+// APIApp.Group to create groups. The routes in a group are ordinary
+// APIRoutes: when the group's definition function returns, gimlet
+// applies the group's settings to every route in the group:
+//
+//   - the group's prefix is prepended to each route's path,
+//
+//   - routes without a version use the group's version,
+//
+//   - routes without methods use the group's methods, and
+//
+//   - the group's wrappers run before each route's own wrappers.
+//
+// Nested groups inherit the settings of their parent groups.
+type RouteGroup struct {
+	app         *APIApp
+	prefix      string
+	version     int
+	lastVersion int
+	methods     []string
+	wrappers    []interface{}
+	routes      []*APIRoute
+}
+
+// Group creates a group of routes, which the definition function
+// adds to the group.
+func (a *APIApp) Group(fn func(g *RouteGroup)) {
+	g := &RouteGroup{app: a, version: -1}
+	fn(g)
+	g.apply()
+}
+
+// Group creates a nested group of routes, which inherits the
+// settings of this group.
+func (g *RouteGroup) Group(fn func(g *RouteGroup)) {
+	nested := &RouteGroup{app: g.app, version: -1}
+	fn(nested)
+	nested.apply()
+
+	g.routes = append(g.routes, nested.routes...)
+}
+
+// AddRoute creates a new route in the group.
+func (g *RouteGroup) AddRoute(r string) *APIRoute {
+	route := g.app.AddRoute(r)
+	g.routes = append(g.routes, route)
+	return route
+}
+
+// AddPrefixRoute creates a new route in the group matching
+// everything under the given prefix.
+func (g *RouteGroup) AddPrefixRoute(prefix string) *APIRoute {
+	route := g.app.AddPrefixRoute(prefix)
+	g.routes = append(g.routes, route)
+	return route
+}
+
+// Prefix sets a path prefix for the routes in the group, which
+// follows the version segment and precedes the route's own path
+// (e.g. "/v1/<prefix>/<route>"). Routes in the group with the path
+// "/" resolve to the prefix itself.
+func (g *RouteGroup) Prefix(p string) *RouteGroup {
+	if !strings.HasPrefix(p, "/") {
+		p = "/" + p
+	}
+
+	g.prefix = strings.TrimSuffix(p, "/")
+	return g
+}
+
+// Version sets the version of routes in the group that do not
+// specify a version.
+func (g *RouteGroup) Version(version int) *RouteGroup {
+	if version < 0 {
+		grip.Warningf("%d is not a valid version", version)
+	}
+
+	g.version = version
+	g.lastVersion = 0
+	return g
+}
+
+// Versions sets the version range of routes in the group that do not
+// specify a version.
+func (g *RouteGroup) Versions(first, last int) *RouteGroup {
+	if first < 0 || last < first {
+		grip.Warningf("%d-%d is not a valid version range", first, last)
+		return g
+	}
+
+	g.version = first
+	g.lastVersion = last
+	return g
+}
+
+// Methods sets the methods of routes in the group that do not
+// specify methods.
+func (g *RouteGroup) Methods(methods ...string) *RouteGroup {
+	g.methods = append(g.methods, methods...)
+	return g
+}
+
+// Wrap adds middleware to all routes in the group.
+func (g *RouteGroup) Wrap(mws ...Middleware) *RouteGroup {
+	for _, m := range mws {
+		g.wrappers = append(g.wrappers, m)
+	}
+	return g
+}
+
+// WrapHandler adds middleware to all routes in the group.
+func (g *RouteGroup) WrapHandler(mws ...HandlerWrapper) *RouteGroup {
+	for _, m := range mws {
+		g.wrappers = append(g.wrappers, m)
+	}
+	return g
+}
+
+// WrapHandlerFunc adds middleware to all routes in the group.
+func (g *RouteGroup) WrapHandlerFunc(mws ...HandlerFuncWrapper) *RouteGroup {
+	for _, m := range mws {
+		g.wrappers = append(g.wrappers, m)
+	}
+	return g
+}
+
+func (g *RouteGroup) apply() {
+	for _, route := range g.routes {
+		switch {
+		case g.prefix == "":
+		case route.route == "/":
+			route.route = g.prefix
+		default:
+			route.route = g.prefix + route.route
+		}
+
+		if route.version < 0 && g.version >= 0 {
+			route.version = g.version
+			route.lastVersion = g.lastVersion
+		}
+
+		if len(route.methods) == 0 {
+			for _, m := range g.methods {
+				route.Method(strings.ToUpper(m))
+			}
+		}
+
+		if len(g.wrappers) > 0 {
+			wrappers := make([]interface{}, 0, len(g.wrappers)+len(route.wrappers))
+			route.wrappers = append(append(wrappers, g.wrappers...), route.wrappers...)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteGroups(t *testing.T) {
+	record := func(name string) HandlerFuncWrapper {
+		return func(next http.HandlerFunc) http.HandlerFunc {
+			return func(rw http.ResponseWriter, r *http.Request) {
+				rw.Header().Add("X-Wrappers", name)
+				next(rw, r)
+			}
+		}
+	}
+	handler := func(rw http.ResponseWriter, r *http.Request) {
+		v, _ := GetVersion(r.Context())
+		WriteText(rw, fmt.Sprintf("%s %d", r.Method, v))
+	}
+
+	app := NewApp()
+	app.SetPrefix("api")
+	app.Group(func(g *RouteGroup) {
+		g.Prefix("users").Version(2).Methods("GET", "post").WrapHandlerFunc(record("outer"))
+
+		g.AddRoute("/").Handler(handler)
+		g.AddRoute("/{id}").Delete().Handler(handler).WrapHandlerFunc(record("route"))
+		g.AddRoute("/legacy").Version(1).Handler(handler)
+
+		g.Group(func(n *RouteGroup) {
+			n.Prefix("/{id}/keys/").Methods("PUT").WrapHandlerFunc(record("inner"))
+
+			n.AddRoute("/{key}").Get().Handler(handler)
+			n.AddPrefixRoute("/files").Handler(handler)
+		})
+	})
+	app.AddRoute("/outside").Version(1).Get().Handler(handler)
+
+	routes := app.Routes()
+	require.Len(t, routes, 6)
+
+	assert.Equal(t, "/api/v2/users", routes[0].Path)
+	assert.Equal(t, []string{"GET", "POST"}, routes[0].Methods)
+	assert.Equal(t, "/api/v2/users/{id}", routes[1].Path)
+	assert.Equal(t, []string{"DELETE"}, routes[1].Methods)
+	assert.Equal(t, "/api/v1/users/legacy", routes[2].Path)
+	assert.Equal(t, "/api/v2/users/{id}/keys/{key}", routes[3].Path)
+	assert.Equal(t, "/api/v2/users/{id}/keys/files", routes[4].Path)
+	assert.Equal(t, []string{"PUT"}, routes[4].Methods)
+	assert.True(t, routes[4].IsPrefix)
+	assert.Equal(t, "/api/v1/outside", routes[5].Path)
+	assert.Empty(t, routes[5].Wrappers)
+
+	h, err := app.Handler()
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		method   string
+		path     string
+		body     string
+		wrappers string
+	}{
+		{method: http.MethodPost, path: "/api/v2/users", body: "POST 2", wrappers: "outer"},
+		{method: http.MethodDelete, path: "/api/v2/users/1", body: "DELETE 2", wrappers: "outer,route"},
+		{method: http.MethodGet, path: "/api/v1/users/legacy", body: "GET 1", wrappers: "outer"},
+		{method: http.MethodGet, path: "/api/v2/users/1/keys/2", body: "GET 2", wrappers: "outer,inner"},
+		{method: http.MethodPut, path: "/api/v2/users/1/keys/files/a/b", body: "PUT 2", wrappers: "outer,inner"},
+		{method: http.MethodGet, path: "/api/v1/outside", body: "GET 1"},
+	} {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, http.StatusOK, rw.Code, tc.path)
+		assert.Equal(t, tc.body, rw.Body.String(), tc.path)
+		assert.Equal(t, tc.wrappers, strings.Join(rw.Header().Values("X-Wrappers"), ","), tc.path)
+	}
+}
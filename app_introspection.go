@@ -0,0 +1,108 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// RouteInfo describes a route as the application resolves it.
+type RouteInfo struct {
+	Path           string   `bson:"path" json:"path" yaml:"path"`
+	Route          string   `bson:"route" json:"route" yaml:"route"`
+	Prefix         string   `bson:"prefix,omitempty" json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Methods        []string `bson:"methods" json:"methods" yaml:"methods"`
+	Version        int      `bson:"version" json:"version" yaml:"version"`
+	OverridePrefix bool     `bson:"override_prefix" json:"override_prefix" yaml:"override_prefix"`
+	IsPrefix       bool     `bson:"is_prefix" json:"is_prefix" yaml:"is_prefix"`
+	Deprecated     bool     `bson:"deprecated" json:"deprecated" yaml:"deprecated"`
+	WrapperCount   int      `bson:"wrapper_count" json:"wrapper_count" yaml:"wrapper_count"`
+	Wrappers       []string `bson:"wrappers,omitempty" json:"wrappers,omitempty" yaml:"wrappers,omitempty"`
+	Error          string   `bson:"error,omitempty" json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Routes returns descriptions of all routes registered with the
+// application, including routes from merged applications, with the
+// paths that the application's Handler serves. Routes available at
+// more than one version have a description for each version. Routes that the
+// application cannot serve (e.g. because they have no handler or an
+// invalid version) have the Error field set.
+//
+// Wrappers lists the types of all wrappers that apply to the route,
+// both application wrappers and route-specific wrappers, in the
+// order that they run.
+func (a *APIApp) Routes() []RouteInfo {
+	out := make([]RouteInfo, 0, len(a.routes))
+	for _, route := range a.routes {
+		for _, rv := range route.expand() {
+			out = append(out, rv.info(a, true, ""))
+		}
+	}
+	return out
+}
+
+// GetRoutesApp produces an APIApp with a single route that serves
+// the route table of the applications as JSON. The paths reflect
+// the handler that MergeApplications produces for these
+// applications, and the table is computed for each request, so it
+// includes routes added after creating the routes app.
+//
+// Like the application returned by GetPProfApp, you can merge this
+// application into an existing application.
+func GetRoutesApp(apps ...*APIApp) *APIApp {
+	app := NewApp()
+	app.SetPrefix("/debug/routes")
+	app.NoVersions = true
+
+	app.AddRoute("/").Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+		out := []RouteInfo{}
+		for _, a := range apps {
+			for _, route := range a.routes {
+				for _, rv := range route.expand() {
+					if a.prefix != "" {
+						out = append(out, rv.info(a, false, a.prefix))
+					} else {
+						out = append(out, rv.info(a, true, ""))
+					}
+				}
+			}
+		}
+
+		WriteJSON(rw, out)
+	})
+
+	return app
+}
+
+func (r *APIRoute) info(app *APIApp, addAppPrefix bool, mount string) RouteInfo {
+	info := RouteInfo{
+		Route:          r.route,
+		Prefix:         r.prefix,
+		Methods:        make([]string, 0, len(r.methods)),
+		Version:        r.version,
+		OverridePrefix: r.overrideAppPrefix,
+		IsPrefix:       r.isPrefix,
+		Deprecated:     r.isDeprecated(r.version),
+		WrapperCount:   len(app.wrappers) + len(r.wrappers),
+	}
+
+	for _, m := range r.methods {
+		info.Methods = append(info.Methods, m.String())
+	}
+
+	for _, mw := range r.getMiddleware(app.wrappers) {
+		info.Wrappers = append(info.Wrappers, fmt.Sprintf("%T", mw))
+	}
+
+	if !r.IsValid() {
+		info.Error = fmt.Sprintf("%s is not a valid route", r)
+	}
+
+	path, err := r.resolvePath(app, addAppPrefix)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.Path = mount + path
+
+	return info
+}
@@ -0,0 +1,88 @@
+package gimlet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteIntrospection(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	t.Run("Routes", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("api")
+		app.AddWrapper(MakeRecoveryLogger())
+		app.AddRoute("/foo/{id}").Version(2).Get().Patch().Handler(noop).
+			WrapHandlerFunc(func(next http.HandlerFunc) http.HandlerFunc { return next })
+		app.AddPrefixRoute("/files").Prefix("/admin").OverridePrefix().Version(1).Get().Handler(noop)
+		app.AddRoute("/broken").Get().Handler(noop)
+		app.AddRoute("/nohandler").Version(1).Get()
+
+		routes := app.Routes()
+		require.Len(t, routes, 4)
+
+		assert.Equal(t, "/api/v2/foo/{id}", routes[0].Path)
+		assert.Equal(t, "/foo/{id}", routes[0].Route)
+		assert.Equal(t, []string{"GET", "PATCH"}, routes[0].Methods)
+		assert.Equal(t, 2, routes[0].Version)
+		assert.False(t, routes[0].IsPrefix)
+		assert.False(t, routes[0].OverridePrefix)
+		assert.Equal(t, 2, routes[0].WrapperCount)
+		assert.Equal(t, []string{"*gimlet.appRecoveryLogger", "gimlet.HandlerFuncWrapper"}, routes[0].Wrappers)
+		assert.Empty(t, routes[0].Error)
+
+		assert.Equal(t, "/admin/v1/files", routes[1].Path)
+		assert.Equal(t, "/admin", routes[1].Prefix)
+		assert.True(t, routes[1].IsPrefix)
+		assert.True(t, routes[1].OverridePrefix)
+		assert.Equal(t, 1, routes[1].WrapperCount)
+
+		assert.Empty(t, routes[2].Path)
+		assert.Equal(t, -1, routes[2].Version)
+		assert.Contains(t, routes[2].Error, "versioning error")
+
+		assert.Equal(t, "/api/v1/nohandler", routes[3].Path)
+		assert.Contains(t, routes[3].Error, "not a valid route")
+	})
+	t.Run("Merged", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("one")
+		app.AddRoute("/foo").Version(1).Get().Handler(noop)
+
+		root := NewApp()
+		root.AddRoute("/bar").Version(1).Get().Handler(noop)
+		require.NoError(t, root.Merge(app))
+
+		routes := root.Routes()
+		require.Len(t, routes, 2)
+		assert.Equal(t, "/v1/bar", routes[0].Path)
+		assert.Equal(t, "/v1/one/foo", routes[1].Path)
+	})
+	t.Run("App", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("one")
+		app.AddRoute("/foo").Version(1).Get().Handler(noop)
+
+		root := NewApp()
+		root.AddRoute("/bar").Version(1).Get().Handler(noop)
+
+		debug := GetRoutesApp(app, root)
+		h, err := MergeApplications(app, debug, root)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/debug/routes/", nil))
+		require.Equal(t, http.StatusOK, rw.Code)
+
+		routes := []RouteInfo{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &routes))
+		require.Len(t, routes, 2)
+		assert.Equal(t, "/one/v1/foo", routes[0].Path)
+		assert.Equal(t, "/v1/bar", routes[1].Path)
+	})
+}
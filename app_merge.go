@@ -3,6 +3,7 @@ package gimlet
 import (
 	"fmt"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi"
 	"github.com/gorilla/mux"
@@ -17,53 +18,41 @@ import (
 // Eventually the router will become an implementation detail of
 // this/related functions.
 func AssembleHandlerGorilla(router *mux.Router, apps ...*APIApp) (http.Handler, error) {
-	catcher := &erc.Collector{}
-	mws := []interface{}{}
-
-	seenPrefixes := make(map[string]struct{})
+	return assembleHandler(&gorillaAdapter{router: router}, apps...)
+}
 
-	for _, app := range apps {
-		if app.prefix != "" {
-			if _, ok := seenPrefixes[app.prefix]; ok {
-				catcher.Push(errors.Errorf("route prefix '%s' defined more than once", app.prefix))
-			}
-			seenPrefixes[app.prefix] = struct{}{}
+// AssembleHandlerChi takes a chi.Mux and one or more applications
+// and returns an http.Handler.
+func AssembleHandlerChi(router *chi.Mux, apps ...*APIApp) (http.Handler, error) {
+	return assembleHandler(newChiAdapterFor(router), apps...)
+}
 
-			r := router.PathPrefix(app.prefix).Subrouter()
-			catcher.Push(app.attachRoutes(r, false)) // this adds wrapper middlware
-			router.PathPrefix(app.prefix).Handler(buildNegroni(r, app.middleware...))
-		} else {
-			mws = append(mws, app.middleware...)
-			catcher.Push(app.attachRoutes(router, true))
-		}
-	}
+// AssembleHandlerStdlib takes a standard library http.ServeMux and
+// one or more applications and returns an http.Handler.
+func AssembleHandlerStdlib(router *http.ServeMux, apps ...*APIApp) (http.Handler, error) {
+	return assembleHandler(newStdlibAdapterFor(router, new(bool)), apps...)
+}
 
-	if !catcher.Ok() {
-		return nil, catcher.Resolve()
+// AssembleHandlerAdapter takes a RouterAdapter and one or more
+// applications and returns an http.Handler. Applications with a
+// prefix are mounted on the router with their middleware, while the
+// middleware of applications without a prefix wraps the entire
+// handler.
+func AssembleHandlerAdapter(router RouterAdapter, apps ...*APIApp) (http.Handler, error) {
+	if router == nil {
+		return nil, errors.New("must specify a router")
 	}
 
-	return buildNegroni(router, mws), nil
+	return assembleHandler(router, apps...)
 }
 
-func AssembleHandlerChi(router *chi.Mux, apps ...*APIApp) (out http.Handler, err error) {
-	out = router
+func assembleHandler(router RouterAdapter, apps ...*APIApp) (http.Handler, error) {
 	catcher := &erc.Collector{}
-	mws := []interface{}{}
+	mws := MiddlewareStack{}
+	deferred := &deferredRoutes{}
 
 	seenPrefixes := make(map[string]struct{})
 
-	defer func() {
-		if p := recover(); p != nil {
-			catcher.Push(fmt.Errorf("chi.Mux encountered error: %+v", p))
-		}
-		err = catcher.Resolve()
-		if !catcher.Ok() {
-			out = nil
-		}
-	}()
-
-	router.Use(convertMidlewares(mws...)...)
-
 	for _, app := range apps {
 		if app.prefix != "" {
 			if _, ok := seenPrefixes[app.prefix]; ok {
@@ -71,16 +60,28 @@ func AssembleHandlerChi(router *chi.Mux, apps ...*APIApp) (out http.Handler, err
 			}
 			seenPrefixes[app.prefix] = struct{}{}
 
-			router.With(convertMidlewares(app.middleware...)...).Route(app.prefix, func(r chi.Router) {
-				catcher.Push(app.attachRoutes(r, false)) // this adds wrapper middlware
-			})
+			sub, err := router.Mount(app.prefix, app.middleware)
+			if err != nil {
+				catcher.Push(err)
+				continue
+			}
+
+			catcher.Push(app.attachRoutes(sub, false)) // this adds wrapper middlware
 		} else {
 			mws = append(mws, app.middleware...)
-			catcher.Push(app.attachRoutes(router, true))
+			catcher.Push(app.registerRoutes(router, true, deferred))
 		}
 	}
 
-	return
+	// applications without prefixes share the router, so routes
+	// for a path may come from more than one application.
+	catcher.Push(deferred.attach(router))
+
+	if !catcher.Ok() {
+		return nil, catcher.Resolve()
+	}
+
+	return router.Handler(mws)
 }
 
 func convertMidlewares(mws ...interface{}) []func(http.Handler) http.Handler {
@@ -137,14 +138,12 @@ func MergeApplications(apps ...*APIApp) (http.Handler, error) {
 		}
 	}
 
-	switch impl {
-	case RouterImplChi:
-		return AssembleHandlerChi(chi.NewMux(), apps...)
-	case RouterImplGorilla:
-		return AssembleHandlerGorilla(mux.NewRouter(), apps...)
-	default:
-		return nil, errors.New("undefined router implementation")
+	router, err := impl.newAdapter(RouterOptions{StrictSlash: apps[0].StrictSlash})
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
+
+	return assembleHandler(router, apps...)
 }
 
 // Merge takes multiple application instances and merges all of their
@@ -182,14 +181,13 @@ func (a *APIApp) Merge(apps ...*APIApp) error {
 			}
 			seenPrefixes[app.prefix] = struct{}{}
 
-			for _, route := range app.routes {
-				r := a.PrefixRoute(app.prefix).Route(route.route).Version(route.version).Handler(route.handler)
-				for _, m := range route.methods {
-					r = r.Method(m.String())
-				}
+			prefix := app.prefix
+			if !strings.HasPrefix(prefix, "/") {
+				prefix = "/" + prefix
+			}
 
-				r.overrideAppPrefix = route.overrideAppPrefix
-				r.wrappers = append(app.middleware, route.wrappers...)
+			for _, route := range app.routes {
+				a.mergeRoute(route, prefix, app)
 			}
 		} else if app.middleware == nil {
 			for _, r := range app.routes {
@@ -205,12 +203,7 @@ func (a *APIApp) Merge(apps ...*APIApp) error {
 					catcher.Push(fmt.Errorf("cannot merge route '%s' with existing application that already has this route defined", route.route))
 				}
 
-				r := a.Route().Route(route.route).Version(route.version)
-				for _, m := range route.methods {
-					r = r.Method(m.String())
-				}
-				r.overrideAppPrefix = route.overrideAppPrefix
-				r.wrappers = append(app.middleware, route.wrappers...)
+				a.mergeRoute(route, route.prefix, app)
 			}
 		}
 	}
@@ -220,6 +213,18 @@ func (a *APIApp) Merge(apps ...*APIApp) error {
 	return catcher.Resolve()
 }
 
+// mergeRoute adds a copy of a route from another application, with
+// the prefix, the middleware and the options of that application.
+func (a *APIApp) mergeRoute(route *APIRoute, prefix string, app *APIApp) {
+	r := *route
+	r.prefix = prefix
+	r.methods = append([]httpMethod(nil), route.methods...)
+	r.wrappers = append(append([]interface{}{}, app.middleware...), route.wrappers...)
+	r.problemDetails = route.problemDetails || app.problemDetails
+
+	a.routes = append(a.routes, &r)
+}
+
 func (a *APIApp) containsRoute(path string, version int, methods []httpMethod) bool {
 	for _, r := range a.routes {
 		if r.route == path && r.version == version {
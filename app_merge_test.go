@@ -2,7 +2,9 @@ package gimlet
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi"
 	"github.com/gorilla/mux"
@@ -19,6 +21,14 @@ func TestMergeApplications(t *testing.T) {
 		"Chi": func() *APIApp {
 			return NewApp().SetRouter(RouterImplChi)
 		},
+		"Stdlib": func() *APIApp {
+			return NewApp().SetRouter(RouterImplStdlib)
+		},
+		"StdlibNoSlash": func() *APIApp {
+			app := NewApp().SetRouter(RouterImplStdlib)
+			app.StrictSlash = false
+			return app
+		},
 		"GorillaNoSlash": func() *APIApp {
 			app := NewApp().SetRouter(RouterImplGorilla)
 			app.StrictSlash = false
@@ -216,6 +226,46 @@ func TestAssembleHandler(t *testing.T) {
 		assert.NoError(t, err)
 		assert.NotNil(t, h)
 
+		// routes with methods in applications without a prefix
+		app = NewApp()
+		app.AddRoute("/bar").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) { rw.WriteHeader(http.StatusTeapot) })
+		h, err = AssembleHandlerChi(chi.NewMux(), app)
+		require.NoError(t, err)
+		require.NotNil(t, h)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/bar", nil))
+		assert.Equal(t, http.StatusTeapot, rw.Code)
+	})
+	t.Run("Stdlib", func(t *testing.T) {
+		app := NewApp()
+		app.AddRoute("/foo").version = -1
+
+		h, err := AssembleHandlerStdlib(http.NewServeMux(), app)
+		assert.Error(t, err)
+		assert.Nil(t, h)
+
+		app = NewApp()
+		app.SetPrefix("foo")
+		app.AddMiddleware(MakeRecoveryLogger())
+
+		h, err = AssembleHandlerStdlib(http.NewServeMux(), app)
+		assert.NoError(t, err)
+		assert.NotNil(t, h)
+
+		// can't have duplicated methods
+		app2 := NewApp()
+		app2.SetPrefix("foo")
+		h, err = AssembleHandlerStdlib(http.NewServeMux(), app, app2)
+		assert.Error(t, err)
+		assert.Nil(t, h)
+
+		app = NewApp()
+		app.StrictSlash = false
+		app.AddMiddleware(MakeRecoveryLogger())
+		h, err = AssembleHandlerStdlib(http.NewServeMux(), app)
+		assert.NoError(t, err)
+		assert.NotNil(t, h)
 	})
 	t.Run("MixedRouters", func(t *testing.T) {
 		_, err := MergeApplications(NewApp(), NewApp())
@@ -230,11 +280,53 @@ func TestAssembleHandler(t *testing.T) {
 		app := NewApp()
 		app.AddRoute("/foo").Version(1).Get().Handler(func(_ http.ResponseWriter, _ *http.Request) {})
 
-		require.Error(t, app.attachRoutes(true, true))
+		require.Error(t, app.attachRoutes(nil, true))
 	})
 
 }
 
+func TestMergeCopiesRoutes(t *testing.T) {
+	mw := MakeRecoveryLogger()
+	for name, prefix := range map[string]string{"Prefixed": "child", "Unprefixed": ""} {
+		t.Run(name, func(t *testing.T) {
+			child := NewApp()
+			child.SetPrefix(prefix)
+			child.AddMiddleware(mw)
+			original := child.AddPrefixRoute("/files").Versions(1, 2).Latest().Get().Post().
+				Name("files").Summary("files").MaxRequestSize(1024).ETags(ETagWeak).
+				Deprecated(RouteDeprecation{Sunset: time.Now()}).
+				Handler(func(_ http.ResponseWriter, _ *http.Request) {})
+
+			parent := NewApp()
+			require.NoError(t, parent.Merge(child))
+			require.Len(t, parent.routes, 1)
+
+			merged := parent.routes[0]
+			assert.NotSame(t, original, merged)
+			assert.Equal(t, "/files", merged.route)
+			if prefix != "" {
+				assert.Equal(t, "/child", merged.prefix)
+			}
+			assert.Equal(t, original.methods, merged.methods)
+			assert.NotNil(t, merged.handler)
+			assert.Equal(t, original.version, merged.version)
+			assert.Equal(t, original.lastVersion, merged.lastVersion)
+			assert.True(t, merged.latest)
+			assert.True(t, merged.isPrefix)
+			assert.Equal(t, "files", merged.name)
+			assert.Equal(t, "files", merged.doc.summary)
+			assert.EqualValues(t, 1024, merged.maxRequestSize)
+			assert.Equal(t, ETagWeak, merged.etagMode)
+			assert.Equal(t, original.deprecation, merged.deprecation)
+			assert.Equal(t, []interface{}{mw}, merged.wrappers)
+
+			// the merged route is independent of the original
+			merged.Put()
+			assert.Len(t, original.methods, 2)
+		})
+	}
+}
+
 func TestAppContainsRoute(t *testing.T) {
 	app := NewApp()
 	app.AddRoute("/foo").Get().Version(2)
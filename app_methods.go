@@ -0,0 +1,103 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// allowedMethods collects the methods registered for each path, so
+// that requests using other methods receive the same responses
+// regardless of the router implementation: OPTIONS requests receive
+// a 204 and all other methods receive a 405, both with an Allow
+// header listing the methods for the path.
+type allowedMethods struct {
+	order   []allowedMethodsKey
+	paths   map[allowedMethodsKey]string
+	methods map[allowedMethodsKey]map[string]struct{}
+}
+
+// allowedMethodsKey identifies the routes that routers treat as the
+// same route: the pattern omits the names and expressions of
+// variables, so "/x/{id}" and "/x/{name}" share methods.
+type allowedMethodsKey struct {
+	pattern  string
+	isPrefix bool
+}
+
+func (a *allowedMethods) add(path string, isPrefix bool, methods []string) {
+	if a.methods == nil {
+		a.methods = map[allowedMethodsKey]map[string]struct{}{}
+		a.paths = map[allowedMethodsKey]string{}
+	}
+
+	key := allowedMethodsKey{pattern: normalizeRoutePattern(path), isPrefix: isPrefix}
+	if _, ok := a.methods[key]; !ok {
+		a.order = append(a.order, key)
+		a.paths[key] = path
+		a.methods[key] = map[string]struct{}{}
+	}
+
+	for _, m := range methods {
+		a.methods[key][m] = struct{}{}
+	}
+}
+
+// normalizeRoutePattern removes the names and expressions of the
+// variables in a route, so that routes that match the same paths
+// have the same pattern. Wildcards that match the rest of the path
+// (e.g. "{path...}") remain distinct from other variables.
+func normalizeRoutePattern(path string) string {
+	pattern, _, err := convertStdlibPattern(path)
+	if err != nil {
+		return path
+	}
+
+	return routeVariablePattern.ReplaceAllString(pattern, "{$1}")
+}
+
+var routeVariablePattern = regexp.MustCompile(`\{[^{}]*?(\.\.\.)?\}`)
+
+// attach registers a route, without methods, for every path. Routers
+// must only dispatch these routes for methods that no other route
+// handles.
+func (a *allowedMethods) attach(router RouterAdapter) error {
+	for _, key := range a.order {
+		allow := make([]string, 0, len(a.methods[key])+1)
+		for m := range a.methods[key] {
+			allow = append(allow, m)
+		}
+		if _, ok := a.methods[key][http.MethodOptions]; !ok {
+			allow = append(allow, http.MethodOptions)
+		}
+		sort.Strings(allow)
+
+		if err := router.AddRoute(RouteDefinition{
+			Path:     a.paths[key],
+			IsPrefix: key.isPrefix,
+			Handler:  methodNotAllowedHandler(strings.Join(allow, ", ")),
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func methodNotAllowedHandler(allow string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Header().Set("Allow", allow)
+
+		if r.Method == http.MethodOptions {
+			rw.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		WriteJSONResponse(rw, http.StatusMethodNotAllowed, ErrorResponse{
+			StatusCode: http.StatusMethodNotAllowed,
+			Message:    fmt.Sprintf("method %s is not allowed for %s", r.Method, r.URL.Path),
+		})
+	})
+}
@@ -0,0 +1,156 @@
+package gimlet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMethodNotAllowed(t *testing.T) {
+	ok := func(rw http.ResponseWriter, r *http.Request) { WriteText(rw, r.Method) }
+
+	for name, impl := range map[string]RouterImplementation{
+		"Gorilla": RouterImplGorilla,
+		"Chi":     RouterImplChi,
+		"Stdlib":  RouterImplStdlib,
+	} {
+		t.Run(name, func(t *testing.T) {
+			t.Run("Application", func(t *testing.T) {
+				app := NewApp()
+				app.SetRouter(impl)
+				app.SetPrefix("api")
+				app.AddRoute("/foo/{id}").Version(1).Get().Handler(ok)
+				app.AddRoute("/foo/{id}").Version(1).Put().Delete().Handler(ok)
+				app.AddPrefixRoute("/files").Version(1).Get().Handler(ok)
+				app.AddRoute("/cors").Version(1).Post().Options().Handler(ok)
+				app.AddRoute("/head").Version(1).Get().Head().Handler(ok)
+
+				h, err := app.Handler()
+				require.NoError(t, err)
+
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/api/v1/foo/1", nil))
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, http.MethodPut, rw.Body.String())
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/api/v1/foo/1", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "DELETE, GET, OPTIONS, PUT", rw.Header().Get("Allow"))
+				resp := ErrorResponse{}
+				require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+				assert.Equal(t, http.StatusMethodNotAllowed, resp.StatusCode)
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodOptions, "/api/v1/foo/1", nil))
+				assert.Equal(t, http.StatusNoContent, rw.Code)
+				assert.Equal(t, "DELETE, GET, OPTIONS, PUT", rw.Header().Get("Allow"))
+				assert.Empty(t, rw.Body.String())
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodDelete, "/api/v1/files/a/b", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "GET, OPTIONS", rw.Header().Get("Allow"))
+
+				// HEAD requests only reach routes that specify HEAD
+				for path, allow := range map[string]string{
+					"/api/v1/foo/1":     "DELETE, GET, OPTIONS, PUT",
+					"/api/v1/files/a/b": "GET, OPTIONS",
+				} {
+					rw = httptest.NewRecorder()
+					h.ServeHTTP(rw, httptest.NewRequest(http.MethodHead, path, nil))
+					assert.Equal(t, http.StatusMethodNotAllowed, rw.Code, path)
+					assert.Equal(t, allow, rw.Header().Get("Allow"), path)
+				}
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodHead, "/api/v1/head", nil))
+				assert.Equal(t, http.StatusOK, rw.Code)
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodOptions, "/api/v1/cors", nil))
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, http.MethodOptions, rw.Body.String())
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v1/cors", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "OPTIONS, POST", rw.Header().Get("Allow"))
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v1/missing", nil))
+				assert.Equal(t, http.StatusNotFound, rw.Code)
+			})
+			t.Run("MergedApplications", func(t *testing.T) {
+				one := NewApp()
+				one.SetRouter(impl)
+				one.AddRoute("/foo").Version(1).Get().Handler(ok)
+
+				two := NewApp()
+				two.SetRouter(impl)
+				two.AddRoute("/foo").Version(1).Post().Handler(ok)
+
+				prefixed := NewApp()
+				prefixed.SetRouter(impl)
+				prefixed.SetPrefix("bar")
+				prefixed.AddRoute("/baz").Version(1).Patch().Handler(ok)
+
+				h, err := MergeApplications(one, two, prefixed)
+				require.NoError(t, err)
+
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/v1/foo", nil))
+				assert.Equal(t, http.StatusOK, rw.Code)
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/v1/foo", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "GET, OPTIONS, POST", rw.Header().Get("Allow"))
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/bar/v1/baz", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "OPTIONS, PATCH", rw.Header().Get("Allow"))
+			})
+			t.Run("VariableNames", func(t *testing.T) {
+				app := NewApp()
+				app.SetRouter(impl)
+				app.NoVersions = true
+				app.AddRoute("/x/{id}").Get().Handler(ok)
+				app.AddRoute("/x/{name}").Post().Handler(ok)
+
+				h, err := app.Handler()
+				require.NoError(t, err)
+
+				for _, method := range []string{http.MethodGet, http.MethodPost} {
+					rw := httptest.NewRecorder()
+					h.ServeHTTP(rw, httptest.NewRequest(method, "/x/1", nil))
+					assert.Equal(t, http.StatusOK, rw.Code, method)
+					assert.Equal(t, method, rw.Body.String())
+				}
+
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodPut, "/x/1", nil))
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "GET, OPTIONS, POST", rw.Header().Get("Allow"))
+			})
+		})
+	}
+}
+
+func TestNormalizeRoutePattern(t *testing.T) {
+	for path, pattern := range map[string]string{
+		"/x/{id}":          "/x/{}",
+		"/x/{name}/y":      "/x/{}/y",
+		"/x/{id:[0-9]{2}}": "/x/{}",
+		"/files/{path...}": "/files/{...}",
+		"/static":          "/static",
+		"/x/{unbalanced":   "/x/{unbalanced",
+	} {
+		assert.Equal(t, pattern, normalizeRoutePattern(path), path)
+	}
+}
@@ -3,11 +3,7 @@ package gimlet
 import (
 	"fmt"
 	"net/http"
-	"strings"
 
-	"github.com/go-chi/chi"
-	"github.com/go-chi/chi/middleware"
-	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
 	"github.com/tychoish/fun/erc"
 	"github.com/tychoish/grip/recovery"
@@ -17,14 +13,11 @@ import (
 // Handler returns a handler interface for integration with other
 // server frameworks.
 func (a *APIApp) Handler() (http.Handler, error) {
-	switch a.routerImpl {
-	case RouterImplGorilla:
-		return a.getNegroni()
-	case RouterImplChi:
-		return a.getChi()
-	default:
-		return nil, errors.Errorf("invalid router specified")
+	if err := a.Resolve(); err != nil {
+		return nil, err
 	}
+
+	return a.adapter.Handler(a.middleware)
 }
 
 // Resolve processes the data in an application instance, including
@@ -57,31 +50,20 @@ func (a *APIApp) Resolve() error {
 		return catcher.Resolve()
 	}
 
-	switch a.routerImpl {
-	case RouterImplGorilla:
-		if a.router == nil {
-			a.router = mux.NewRouter().StrictSlash(a.StrictSlash)
-		}
-
-		if err := a.attachRoutes(a.router, true); err != nil {
+	if a.adapter == nil {
+		adapter, err := a.routerImpl.newAdapter(RouterOptions{StrictSlash: a.StrictSlash})
+		if err != nil {
 			return errors.WithStack(err)
 		}
+		a.adapter = adapter
+	}
 
-		a.isResolved = true
-	case RouterImplChi:
-		if a.mux == nil {
-			a.mux = chi.NewMux()
-			if !a.StrictSlash {
-				a.mux.Use(middleware.StripSlashes)
-			}
-		}
-
-		if err := a.attachRoutes(a.mux, true); err != nil {
-			return errors.WithStack(err)
-		}
-		a.isResolved = true
+	if err := a.attachRoutes(a.adapter, true); err != nil {
+		return errors.WithStack(err)
 	}
 
+	a.isResolved = true
+
 	return nil
 }
 
@@ -99,7 +81,7 @@ func iterMerge(sl ...[]interface{}) <-chan interface{} {
 	return out
 }
 
-// getHander internal helper resolves the negorni middleware for the
+// getNegroni internal helper resolves the negroni middleware for the
 // application and returns it in the form of a http.Handler for use in
 // stitching together applications.
 func (a *APIApp) getNegroni() (*negroni.Negroni, error) {
@@ -107,54 +89,64 @@ func (a *APIApp) getNegroni() (*negroni.Negroni, error) {
 		return nil, err
 	}
 
-	n := negroni.New()
-	for _, m := range a.middleware {
-		switch mw := m.(type) {
-		case Middleware:
-			n.Use(mw)
-		case HandlerFuncWrapper:
-			n.Use(WrapperMiddleware(mw))
-		case HandlerWrapper:
-			n.Use(WrapperHandlerMiddleware(mw))
-		}
+	router, err := a.adapter.Handler(nil)
+	if err != nil {
+		return nil, errors.WithStack(err)
 	}
 
-	n.UseHandler(a.router)
-
-	return n, nil
+	return buildNegroni(router, a.middleware...), nil
 }
 
-func (a *APIApp) getChi() (*chi.Mux, error) {
-	a.mux = chi.NewMux()
-	if !a.StrictSlash {
-		a.mux.Use(middleware.StripSlashes)
+func (a *APIApp) attachRoutes(router RouterAdapter, addAppPrefix bool) error {
+	deferred := &deferredRoutes{}
+	if err := a.registerRoutes(router, addAppPrefix, deferred); err != nil {
+		return err
 	}
 
-	for _, m := range a.middleware {
-		switch mw := m.(type) {
-		case Middleware:
-			a.mux.Use(MiddlewareFunc(mw))
-		case HandlerFuncWrapper:
-			a.mux.Use(MiddlewareFunc(mw))
-		case HandlerWrapper:
-			a.mux.Use(mw)
-		}
-	}
+	return deferred.attach(router)
+}
 
-	if err := a.Resolve(); err != nil {
-		return nil, err
+// deferredRoutes holds the routes that gimlet registers after all
+// other routes that share a router: routes that dispatch requests
+// to the negotiated version of a route, and routes that answer
+// requests for methods that no route handles.
+type deferredRoutes struct {
+	versioned versionedRoutes
+	allowed   allowedMethods
+}
+
+func (d *deferredRoutes) attach(router RouterAdapter) error {
+	if err := d.versioned.attach(router); err != nil {
+		return err
 	}
 
-	return a.mux, nil
+	return d.allowed.attach(router)
 }
 
-func (a *APIApp) attachRoutes(muxer interface{}, addAppPrefix bool) error {
-	if router, ok := muxer.(*mux.Router); ok {
-		router.StrictSlash(a.StrictSlash)
+// registerRoutes adds the application's routes to the router,
+// recording the routes that callers must attach, with
+// deferred.attach, after registering all routes that share the
+// router.
+func (a *APIApp) registerRoutes(router RouterAdapter, addAppPrefix bool, deferred *deferredRoutes) error {
+	if router == nil {
+		return errors.New("router is not defined")
+	}
+
+	urls := &urlResolver{app: a, addAppPrefix: addAppPrefix}
+	if !addAppPrefix {
+		urls.mount = a.prefix
 	}
 
 	catcher := &erc.Collector{}
+	names := map[string]struct{}{}
 	for _, route := range a.routes {
+		if route.name != "" {
+			if _, ok := names[route.name]; ok {
+				catcher.Push(fmt.Errorf("route name '%s' is defined more than once", route.name))
+			}
+			names[route.name] = struct{}{}
+		}
+
 		if !route.IsValid() {
 			catcher.Push(fmt.Errorf("%s is not a valid route, skipping", route))
 			continue
@@ -162,16 +154,11 @@ func (a *APIApp) attachRoutes(muxer interface{}, addAppPrefix bool) error {
 
 		var methods []string
 		for _, m := range route.methods {
-			methods = append(methods, strings.ToLower(m.String()))
+			methods = append(methods, m.String())
 		}
 
-		routeString := ""
-		if route.version >= 0 {
-			routeString = route.resolveVersionedRoute(a, addAppPrefix)
-		} else if a.NoVersions {
-			routeString = route.resolveLegacyRoute(a, addAppPrefix)
-		} else {
-			catcher.Push(fmt.Errorf("skipping '%s', because of versioning error", route))
+		if _, err := route.resolvePath(a, addAppPrefix); err != nil {
+			catcher.Push(err)
 			continue
 		}
 
@@ -194,35 +181,69 @@ func (a *APIApp) attachRoutes(muxer interface{}, addAppPrefix bool) error {
 			continue
 		}
 
-		switch router := muxer.(type) {
-		case *mux.Router:
-			handler := route.getHandlerWithMiddlware(a.wrappers)
-			if route.isPrefix {
-				router.PathPrefix(routeString).Handler(handler).Methods(methods...)
-			} else {
-				router.Handle(routeString, handler).Methods(methods...)
+		for _, rv := range route.expand() {
+			handler := withRouterAdapter(router, urls, rv.handler)
+			if rv.isDeprecated(rv.version) {
+				handler = withDeprecation(rv, handler)
+			}
+			if rv.version >= 0 {
+				handler = withVersion(rv.version, handler)
+			}
+			if rv.maxRequestSize > 0 {
+				handler = withMaxRequestSize(rv.maxRequestSize, handler)
+			}
+			if rv.etagMode != ETagStrong {
+				handler = withETagMode(rv.etagMode, handler)
+			}
+			if rv.problemDetails || a.problemDetails {
+				handler = withProblemDetails(handler)
+			}
+
+			if rv.version < 0 || a.versioning.byPath() {
+				routeString, _ := rv.resolvePath(a, addAppPrefix)
+				deferred.allowed.add(routeString, rv.isPrefix, methods)
+				catcher.Push(router.AddRoute(RouteDefinition{
+					Path:        routeString,
+					Methods:     methods,
+					IsPrefix:    rv.isPrefix,
+					StrictSlash: a.StrictSlash,
+					Handler:     handler,
+					Middleware:  rv.getMiddleware(a.wrappers),
+				}))
 			}
-		case *chi.Mux:
-			mws := route.getMiddlewareSlice(a.wrappers)
 
-			if len(mws) == 0 {
-				for idx := range methods {
-					router.Method(methods[idx], routeString, route.handler)
-				}
-			} else {
-				for idx := range methods {
-					router.With(mws...).Method(methods[idx], routeString, route.handler)
+			// the latest alias is a path segment: applications
+			// that negotiate versions resolve "latest" when
+			// dispatching requests.
+			if rv.version >= 0 && !rv.latestAlias && a.versioning.negotiated() {
+				path := rv.resolveLegacyRoute(a, addAppPrefix)
+				handler = rv.getMiddleware(a.wrappers).Handler(handler)
+
+				deferred.allowed.add(path, rv.isPrefix, methods)
+				for _, m := range methods {
+					catcher.Push(deferred.versioned.add(a, path, rv.isPrefix, m, rv.version, handler))
 				}
 			}
-
-		default:
-			return errors.Errorf("%T is not a valid mux type", muxer)
 		}
 	}
 
 	return catcher.Resolve()
 }
 
+// resolvePath returns the full path of the route, as registered with
+// the router, or an error if the route's version is not valid for the
+// application.
+func (r *APIRoute) resolvePath(app *APIApp, addAppPrefix bool) (string, error) {
+	switch {
+	case r.version >= 0:
+		return r.resolveVersionedRoute(app, addAppPrefix), nil
+	case app.NoVersions:
+		return r.resolveLegacyRoute(app, addAppPrefix), nil
+	default:
+		return "", fmt.Errorf("skipping '%s', because of versioning error", r)
+	}
+}
+
 func (r *APIRoute) getRoutePrefix(app *APIApp, addAppPrefix bool) string {
 	if !addAppPrefix {
 		return ""
@@ -254,6 +275,14 @@ func (r *APIRoute) resolveLegacyRoute(app *APIApp, addAppPrefix bool) string {
 }
 
 func (r *APIRoute) getVersionPart(app *APIApp) string {
+	if !app.versioning.byPath() {
+		return ""
+	}
+
+	if r.latestAlias {
+		return "/" + latestVersionAlias
+	}
+
 	var versionPrefix string
 
 	if !app.SimpleVersions {
@@ -287,41 +316,11 @@ func (r *APIRoute) resolveVersionedRoute(app *APIApp, addAppPrefix bool) string
 	return output
 }
 
-func (r *APIRoute) getHandlerWithMiddlware(mws []interface{}) http.Handler {
-	if len(mws) == 0 && len(r.wrappers) == 0 {
-		return r.handler
-	}
-
-	n := negroni.New()
-
-	for m := range iterMerge(mws, r.wrappers) {
-		switch mw := m.(type) {
-		case HandlerFuncWrapper:
-			n.Use(WrapperMiddleware(mw))
-		case HandlerWrapper:
-			n.Use(mw)
-		case Middleware:
-			n.Use(mw)
-		}
-	}
-
-	n.UseHandler(r.handler)
-	return n
+func (r *APIRoute) getMiddleware(mws []interface{}) MiddlewareStack {
+	out := make(MiddlewareStack, 0, len(mws)+len(r.wrappers))
+	return append(append(out, mws...), r.wrappers...)
 }
 
-func (r *APIRoute) getMiddlewareSlice(mws []interface{}) chi.Middlewares {
-	out := make(chi.Middlewares, 0, len(mws)+len(r.wrappers))
-
-	for m := range iterMerge(mws, r.wrappers) {
-		switch mw := m.(type) {
-		case HandlerWrapper:
-			out = append(out, mw)
-		case HandlerFuncWrapper:
-			out = append(out, MiddlewareFunc(mw))
-		case Middleware:
-			out = append(out, MiddlewareFunc(mw))
-		}
-	}
-
-	return out
+func (r *APIRoute) getHandlerWithMiddlware(mws []interface{}) http.Handler {
+	return r.getMiddleware(mws).Handler(r.handler)
 }
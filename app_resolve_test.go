@@ -106,7 +106,7 @@ func TestPrefixRoute(t *testing.T) {
 	app := NewApp()
 	app.NoVersions = true
 	app.AddPrefixRoute("/match/everything/under/this/path").Handler(func(http.ResponseWriter, *http.Request) {}).Get()
-	assert.NoError(t, app.attachRoutes(router, false))
+	assert.NoError(t, app.attachRoutes(&gorillaAdapter{router: router}, false))
 
 	// match the path itself
 	req, err := http.NewRequest("GET", "http://www.example.com/match/everything/under/this/path", bytes.NewBuffer([]byte{}))
@@ -0,0 +1,163 @@
+package gimlet
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// RouterAdapter describes the operations that gimlet needs from an
+// underlying routing implementation. The gorilla, chi, and standard
+// library routers are implemented as adapters, and applications can
+// use other routers by registering a RouterAdapterFactory with
+// RegisterRouter and passing the resulting RouterImplementation to
+// APIApp.SetRouter.
+type RouterAdapter interface {
+	// AddRoute registers a handler for the route. Implementations
+	// must apply the route's middleware, and should return an
+	// error rather than panicking if the route conflicts with an
+	// existing route.
+	AddRoute(RouteDefinition) error
+
+	// Mount returns an adapter whose routes are registered
+	// beneath the prefix. Requests to routes in the mounted
+	// adapter must pass through the middleware.
+	Mount(prefix string, middleware MiddlewareStack) (RouterAdapter, error)
+
+	// Param returns the value of a URL parameter for a request
+	// that this router dispatched.
+	Param(r *http.Request, key string) string
+
+	// Handler produces the final handler for the router, wrapped
+	// in the (application level) middleware.
+	Handler(middleware MiddlewareStack) (http.Handler, error)
+}
+
+// RouteDefinition is the resolved form of an APIRoute passed to
+// RouterAdapter implementations. The path includes any application
+// prefix and version segments, and the methods are upper case.
+//
+// Definitions without methods are registered after all other routes
+// for the path, and must match requests for the path with any method
+// that the other routes do not handle.
+type RouteDefinition struct {
+	Path        string
+	Methods     []string
+	IsPrefix    bool
+	StrictSlash bool
+	Handler     http.Handler
+	Middleware  MiddlewareStack
+}
+
+// RouterOptions holds the application configuration passed to
+// RouterAdapterFactory functions.
+type RouterOptions struct {
+	StrictSlash bool
+}
+
+// RouterAdapterFactory constructs a new, empty RouterAdapter.
+type RouterAdapterFactory func(RouterOptions) RouterAdapter
+
+// MiddlewareStack is an ordered sequence of gimlet middleware: each
+// element is a Middleware, HandlerWrapper or HandlerFuncWrapper.
+type MiddlewareStack []interface{}
+
+// Handler wraps the handler in all middleware in the stack, using
+// negroni. If the stack is empty, the handler is returned unmodified.
+func (m MiddlewareStack) Handler(h http.Handler) http.Handler {
+	if len(m) == 0 {
+		return h
+	}
+
+	return buildNegroni(h, m...)
+}
+
+// Wrappers converts all middleware in the stack into function
+// wrappers, as used by chi and other routers.
+func (m MiddlewareStack) Wrappers() []func(http.Handler) http.Handler {
+	return convertMidlewares(m...)
+}
+
+var routerRegistry = &struct {
+	sync.RWMutex
+	names     []string
+	factories []RouterAdapterFactory
+}{
+	names:     []string{"<undefined>", "gorilla", "chi", "stdlib"},
+	factories: []RouterAdapterFactory{nil, newGorillaAdapter, newChiAdapter, newStdlibAdapter},
+}
+
+// RegisterRouter adds a router implementation to gimlet's registry,
+// returning a RouterImplementation which you can pass to
+// APIApp.SetRouter. Names must be unique.
+func RegisterRouter(name string, factory RouterAdapterFactory) (RouterImplementation, error) {
+	if name == "" {
+		return RouterImplUndefined, errors.New("must specify a router name")
+	}
+
+	if factory == nil {
+		return RouterImplUndefined, errors.Errorf("must specify a factory for router '%s'", name)
+	}
+
+	routerRegistry.Lock()
+	defer routerRegistry.Unlock()
+
+	for _, n := range routerRegistry.names {
+		if n == name {
+			return RouterImplUndefined, errors.Errorf("router '%s' is already registered", name)
+		}
+	}
+
+	routerRegistry.names = append(routerRegistry.names, name)
+	routerRegistry.factories = append(routerRegistry.factories, factory)
+
+	return RouterImplementation(len(routerRegistry.names) - 1), nil
+}
+
+func (r RouterImplementation) lookup() (string, RouterAdapterFactory, bool) {
+	routerRegistry.RLock()
+	defer routerRegistry.RUnlock()
+
+	if r <= RouterImplUndefined || int(r) >= len(routerRegistry.names) {
+		return "", nil, false
+	}
+
+	return routerRegistry.names[r], routerRegistry.factories[r], true
+}
+
+func (r RouterImplementation) newAdapter(opts RouterOptions) (RouterAdapter, error) {
+	if err := r.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, factory, _ := r.lookup()
+	adapter := factory(opts)
+	if adapter == nil {
+		return nil, errors.Errorf("router '%s' produced a nil adapter", r)
+	}
+
+	return adapter, nil
+}
+
+// withRouterAdapter attaches the adapter to the request so that
+// GetParam can use the router that dispatched the request, and the
+// application's routes so that GetURL can resolve named routes.
+func withRouterAdapter(router RouterAdapter, urls *urlResolver, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), routerAdapterKey, router)
+		ctx = context.WithValue(ctx, urlResolverKey, urls)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
+
+func getRouterAdapter(ctx context.Context) RouterAdapter {
+	if rv := ctx.Value(routerAdapterKey); rv != nil {
+		if router, ok := rv.(RouterAdapter); ok {
+			return router
+		}
+	}
+
+	return nil
+}
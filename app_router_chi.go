@@ -0,0 +1,145 @@
+package gimlet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi"
+	"github.com/go-chi/chi/middleware"
+)
+
+type chiAdapter struct {
+	router  chi.Router
+	prefix  string
+	methods map[string]map[string]struct{}
+}
+
+// chiMethods are the methods that chi routes by default.
+var chiMethods = []string{
+	http.MethodConnect,
+	http.MethodDelete,
+	http.MethodGet,
+	http.MethodHead,
+	http.MethodOptions,
+	http.MethodPatch,
+	http.MethodPost,
+	http.MethodPut,
+	http.MethodTrace,
+}
+
+func newChiAdapter(opts RouterOptions) RouterAdapter {
+	mux := chi.NewMux()
+	if !opts.StrictSlash {
+		mux.Use(middleware.StripSlashes)
+	}
+
+	return newChiAdapterFor(mux)
+}
+
+// newChiAdapterFor wraps an existing chi router in an adapter.
+func newChiAdapterFor(router chi.Router) *chiAdapter {
+	return &chiAdapter{router: router, methods: map[string]map[string]struct{}{}}
+}
+
+func (a *chiAdapter) AddRoute(def RouteDefinition) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("chi.Mux encountered error: %+v", p)
+		}
+	}()
+
+	router := a.router
+	if len(def.Middleware) > 0 {
+		router = router.With(def.Middleware.Wrappers()...)
+	}
+
+	paths := []string{def.Path}
+	if def.IsPrefix {
+		paths = append(paths, strings.TrimSuffix(def.Path, "/")+"/*")
+	}
+
+	// chi treats routes that only differ in the names of their
+	// variables as the same route.
+	pattern := normalizeRoutePattern(def.Path)
+	methods := def.Methods
+	if len(methods) == 0 {
+		// chi replaces the handler for a method when routes
+		// share a path, so routes without methods must only
+		// register the methods that no other route handles.
+		methods = []string{}
+		for _, m := range chiMethods {
+			if _, ok := a.methods[pattern][m]; !ok {
+				methods = append(methods, m)
+			}
+		}
+	} else {
+		if _, ok := a.methods[pattern]; !ok {
+			a.methods[pattern] = map[string]struct{}{}
+		}
+		for _, m := range methods {
+			a.methods[pattern][m] = struct{}{}
+		}
+	}
+
+	for _, m := range methods {
+		for _, p := range paths {
+			router.Method(m, p, def.Handler)
+		}
+	}
+
+	return nil
+}
+
+func (a *chiAdapter) Mount(prefix string, mws MiddlewareStack) (_ RouterAdapter, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("chi.Mux encountered error: %+v", p)
+		}
+	}()
+
+	sub := chi.NewRouter()
+	a.router.With(mws.Wrappers()...).Mount(prefix, sub)
+
+	out := newChiAdapterFor(sub)
+	out.prefix = a.prefix + prefix
+	return out, nil
+}
+
+func (a *chiAdapter) Param(r *http.Request, key string) string { return chi.URLParam(r, key) }
+
+func (a *chiAdapter) Handler(mws MiddlewareStack) (http.Handler, error) {
+	var handler http.Handler = a.router
+	if a.prefix != "" {
+		handler = chiMountHandler(a.prefix, a.router)
+	}
+
+	if len(mws) == 0 {
+		return handler, nil
+	}
+
+	return chi.Chain(mws.Wrappers()...).Handler(handler), nil
+}
+
+// chiMountHandler routes requests with the full path through a
+// mounted router, which only matches the part of the path that
+// follows the mount's prefix. Like gorilla's sub-routers, the
+// handler does not run the middleware of the mount.
+func chiMountHandler(prefix string, router http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		path := r.URL.RawPath
+		if path == "" {
+			path = r.URL.Path
+		}
+
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			http.NotFound(rw, r)
+			return
+		}
+
+		rctx := chi.NewRouteContext()
+		rctx.RoutePath = "/" + strings.TrimPrefix(strings.TrimPrefix(path, prefix), "/")
+		router.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx)))
+	})
+}
@@ -0,0 +1,48 @@
+package gimlet
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+type gorillaAdapter struct {
+	router *mux.Router
+}
+
+func newGorillaAdapter(opts RouterOptions) RouterAdapter {
+	return &gorillaAdapter{router: mux.NewRouter().StrictSlash(opts.StrictSlash)}
+}
+
+func (a *gorillaAdapter) AddRoute(def RouteDefinition) error {
+	a.router.StrictSlash(def.StrictSlash)
+
+	var route *mux.Route
+	handler := def.Middleware.Handler(def.Handler)
+	if def.IsPrefix {
+		route = a.router.PathPrefix(def.Path).Handler(handler)
+	} else {
+		route = a.router.Handle(def.Path, handler)
+	}
+
+	// gorilla tries routes in order, so routes without methods
+	// only receive requests that no earlier route matched.
+	if len(def.Methods) > 0 {
+		route.Methods(def.Methods...)
+	}
+
+	return nil
+}
+
+func (a *gorillaAdapter) Mount(prefix string, middleware MiddlewareStack) (RouterAdapter, error) {
+	sub := a.router.PathPrefix(prefix).Subrouter()
+	a.router.PathPrefix(prefix).Handler(buildNegroni(sub, middleware...))
+
+	return &gorillaAdapter{router: sub}, nil
+}
+
+func (a *gorillaAdapter) Param(r *http.Request, key string) string { return mux.Vars(r)[key] }
+
+func (a *gorillaAdapter) Handler(middleware MiddlewareStack) (http.Handler, error) {
+	return buildNegroni(a.router, middleware...), nil
+}
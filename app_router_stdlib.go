@@ -0,0 +1,248 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// stdlibAdapter registers routes with the standard library's
+// http.ServeMux. The mux has no notion of sub-routers, so mounted
+// adapters share the mux and register their routes with the prefix
+// and middleware of the mount.
+type stdlibAdapter struct {
+	mux         *http.ServeMux
+	prefix      string
+	middleware  MiddlewareStack
+	strictSlash *bool
+	methods     map[string]map[string]struct{}
+}
+
+func newStdlibAdapter(opts RouterOptions) RouterAdapter {
+	return newStdlibAdapterFor(http.NewServeMux(), &opts.StrictSlash)
+}
+
+// newStdlibAdapterFor wraps an existing mux in an adapter.
+func newStdlibAdapterFor(mux *http.ServeMux, strictSlash *bool) *stdlibAdapter {
+	return &stdlibAdapter{mux: mux, strictSlash: strictSlash, methods: map[string]map[string]struct{}{}}
+}
+
+func (a *stdlibAdapter) AddRoute(def RouteDefinition) error {
+	*a.strictSlash = *a.strictSlash || def.StrictSlash
+
+	mws := make(MiddlewareStack, 0, len(a.middleware)+len(def.Middleware))
+	mws = append(append(mws, a.middleware...), def.Middleware...)
+
+	path := a.prefix + def.Path
+	handler := mws.Handler(def.Handler)
+	if err := addStdlibRoute(a.mux, path, def.IsPrefix, def.Methods, handler); err != nil {
+		return err
+	}
+
+	pattern := normalizeRoutePattern(path)
+	if len(def.Methods) > 0 {
+		if _, ok := a.methods[pattern]; !ok {
+			a.methods[pattern] = map[string]struct{}{}
+		}
+		for _, m := range def.Methods {
+			a.methods[pattern][strings.ToUpper(m)] = struct{}{}
+		}
+		return nil
+	}
+
+	// the ServeMux dispatches HEAD requests to GET patterns, but
+	// other routers only dispatch HEAD requests to routes that
+	// specify HEAD, so routes without methods must handle HEAD
+	// requests for paths without HEAD routes.
+	_, hasGet := a.methods[pattern][http.MethodGet]
+	_, hasHead := a.methods[pattern][http.MethodHead]
+	if hasGet && !hasHead {
+		return addStdlibRoute(a.mux, path, def.IsPrefix, []string{http.MethodHead}, handler)
+	}
+
+	return nil
+}
+
+func (a *stdlibAdapter) Mount(prefix string, mws MiddlewareStack) (RouterAdapter, error) {
+	out := newStdlibAdapterFor(a.mux, a.strictSlash)
+	out.prefix = a.prefix + prefix
+	out.methods = a.methods
+	out.middleware = append(append(out.middleware, a.middleware...), mws...)
+
+	return out, nil
+}
+
+func (a *stdlibAdapter) Param(r *http.Request, key string) string { return r.PathValue(key) }
+
+func (a *stdlibAdapter) Handler(mws MiddlewareStack) (http.Handler, error) {
+	var handler http.Handler = a.mux
+	if *a.strictSlash {
+		handler = stdlibStrictSlash(a.mux)
+	}
+
+	return mws.Handler(handler), nil
+}
+
+// addStdlibRoute registers a handler with an http.ServeMux for every
+// method specified. Gorilla-style variables with regular expressions
+// (e.g. "{id:[0-9]+}") are converted into standard library wildcards
+// and the expressions are checked before calling the handler: because
+// the ServeMux cannot fall through to another route, requests that
+// fail to match the expression receive a 404.
+func addStdlibRoute(mux *http.ServeMux, path string, isPrefix bool, methods []string, handler http.Handler) error {
+	pattern, constraints, err := convertStdlibPattern(path)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	if len(constraints) > 0 {
+		handler = stdlibConstraintHandler(handler, constraints)
+	}
+
+	var paths []string
+	switch {
+	case isPrefix && strings.HasSuffix(pattern, "/"):
+		paths = []string{pattern}
+	case isPrefix:
+		paths = []string{pattern, pattern + "/"}
+	case strings.HasSuffix(pattern, "/"):
+		// patterns that end in a slash match the entire subtree,
+		// unless anchored to the end of the path.
+		paths = []string{pattern + "{$}"}
+	default:
+		paths = []string{pattern}
+	}
+
+	// patterns without methods are less specific than patterns
+	// with methods, so they only receive requests that no other
+	// pattern for the path matches.
+	if len(methods) == 0 {
+		for _, p := range paths {
+			if err := stdlibHandle(mux, p, handler); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, m := range methods {
+		for _, p := range paths {
+			if err := stdlibHandle(mux, fmt.Sprintf("%s %s", strings.ToUpper(m), p), handler); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// stdlibHandle registers the handler, converting the panics that the
+// ServeMux raises for invalid or conflicting patterns into errors.
+func stdlibHandle(mux *http.ServeMux, pattern string, handler http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errors.Errorf("http.ServeMux encountered error: %+v", p)
+		}
+	}()
+
+	mux.Handle(pattern, handler)
+	return nil
+}
+
+// convertStdlibPattern converts a route with gorilla or chi style
+// variables into a standard library pattern, returning the regular
+// expression constraints for the variables that specify them.
+func convertStdlibPattern(path string) (string, map[string]*regexp.Regexp, error) {
+	var (
+		out         strings.Builder
+		constraints map[string]*regexp.Regexp
+	)
+
+	for idx := 0; idx < len(path); idx++ {
+		if path[idx] != '{' {
+			out.WriteByte(path[idx])
+			continue
+		}
+
+		end := -1
+		depth := 0
+	search:
+		for jdx := idx; jdx < len(path); jdx++ {
+			switch path[jdx] {
+			case '{':
+				depth++
+			case '}':
+				depth--
+				if depth == 0 {
+					end = jdx
+					break search
+				}
+			}
+		}
+
+		if end < 0 {
+			return "", nil, errors.Errorf("route '%s' has unbalanced braces", path)
+		}
+
+		name, expr, hasExpr := strings.Cut(path[idx+1:end], ":")
+		name = strings.TrimSpace(name)
+		out.WriteString("{" + name + "}")
+
+		if hasExpr {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return "", nil, errors.Wrapf(err, "invalid expression for '%s' in route '%s'", name, path)
+			}
+			if constraints == nil {
+				constraints = map[string]*regexp.Regexp{}
+			}
+			constraints[strings.TrimSuffix(name, "...")] = re
+		}
+
+		idx = end
+	}
+
+	return out.String(), constraints, nil
+}
+
+func stdlibConstraintHandler(next http.Handler, constraints map[string]*regexp.Regexp) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		for name, re := range constraints {
+			if !re.MatchString(r.PathValue(name)) {
+				http.NotFound(rw, r)
+				return
+			}
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// stdlibStrictSlash provides the equivalent of gorilla's StrictSlash
+// option for the standard library mux: when a request does not match
+// any route, but would match if the trailing slash were added or
+// removed, the handler redirects to the matching path.
+func stdlibStrictSlash(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if _, pattern := mux.Handler(r); pattern == "" && r.URL.Path != "/" {
+			alt := *r.URL
+			alt.RawPath = ""
+			if strings.HasSuffix(alt.Path, "/") {
+				alt.Path = strings.TrimSuffix(alt.Path, "/")
+			} else {
+				alt.Path += "/"
+			}
+
+			req := *r
+			req.URL = &alt
+			if _, altPattern := mux.Handler(&req); altPattern != "" {
+				http.Redirect(rw, r, alt.String(), http.StatusMovedPermanently)
+				return
+			}
+		}
+
+		mux.ServeHTTP(rw, r)
+	})
+}
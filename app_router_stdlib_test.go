@@ -0,0 +1,199 @@
+package gimlet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdlibPatternConversion(t *testing.T) {
+	for _, tc := range []struct {
+		input       string
+		expected    string
+		constraints []string
+		hasError    bool
+	}{
+		{input: "/foo", expected: "/foo"},
+		{input: "/foo/{id}", expected: "/foo/{id}"},
+		{input: "/foo/{id:[0-9]+}", expected: "/foo/{id}", constraints: []string{"id"}},
+		{input: "/foo/{id:[0-9]{3}}/{name}", expected: "/foo/{id}/{name}", constraints: []string{"id"}},
+		{input: "/foo/{rest...}", expected: "/foo/{rest...}"},
+		{input: "/foo/{id", hasError: true},
+		{input: "/foo/{id:[0-9}", hasError: true},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			out, constraints, err := convertStdlibPattern(tc.input)
+			if tc.hasError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, out)
+			assert.Len(t, constraints, len(tc.constraints))
+			for _, name := range tc.constraints {
+				assert.Contains(t, constraints, name)
+			}
+		})
+	}
+}
+
+func TestStdlibRouting(t *testing.T) {
+	paramHandler := func(rw http.ResponseWriter, r *http.Request) {
+		WriteText(rw, GetParam(r, "id"))
+	}
+
+	t.Run("Versioned", func(t *testing.T) {
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.SetPrefix("api")
+		app.AddRoute("/foo/{id}").Version(2).Get().Handler(paramHandler)
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v2/foo/bar", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "bar", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodPost, "/api/v2/foo/bar", nil))
+		assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v1/foo/bar", nil))
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+	})
+	t.Run("ExpressionConstraints", func(t *testing.T) {
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.NoVersions = true
+		app.AddRoute("/foo/{id:[0-9]+}").Get().Handler(paramHandler)
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/foo/42", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "42", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/foo/bar", nil))
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+	})
+	t.Run("Prefix", func(t *testing.T) {
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.NoVersions = true
+		app.AddPrefixRoute("/match/under").Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+			WriteText(rw, r.URL.Path)
+		})
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		for _, path := range []string{"/match/under", "/match/under/", "/match/under/abcd/efg"} {
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusOK, rw.Code, path)
+			assert.Equal(t, path, rw.Body.String())
+		}
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/another/path", nil))
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+	})
+	t.Run("StrictSlash", func(t *testing.T) {
+		for _, strict := range []bool{true, false} {
+			app := NewApp().SetRouter(RouterImplStdlib)
+			app.StrictSlash = strict
+			app.NoVersions = true
+			app.AddRoute("/foo").Get().Handler(paramHandler)
+			app.AddRoute("/bar/").Get().Handler(paramHandler)
+
+			h, err := app.Handler()
+			require.NoError(t, err)
+
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/foo/", nil))
+			rwTwo := httptest.NewRecorder()
+			h.ServeHTTP(rwTwo, httptest.NewRequest(http.MethodGet, "/bar", nil))
+			rwThree := httptest.NewRecorder()
+			h.ServeHTTP(rwThree, httptest.NewRequest(http.MethodGet, "/bar/baz", nil))
+			assert.Equal(t, http.StatusNotFound, rwThree.Code)
+
+			// the ServeMux always redirects to add a trailing slash
+			assert.Equal(t, http.StatusTemporaryRedirect, rwTwo.Code)
+			assert.Equal(t, "/bar/", rwTwo.Header().Get("Location"))
+
+			if strict {
+				assert.Equal(t, http.StatusMovedPermanently, rw.Code)
+				assert.Equal(t, "/foo", rw.Header().Get("Location"))
+			} else {
+				assert.Equal(t, http.StatusNotFound, rw.Code)
+			}
+		}
+	})
+	t.Run("Wrappers", func(t *testing.T) {
+		counter := 0
+		count := func(next http.HandlerFunc) http.HandlerFunc {
+			return func(rw http.ResponseWriter, r *http.Request) {
+				counter++
+				next(rw, r)
+			}
+		}
+
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.NoVersions = true
+		app.AddMiddlewareFunc(count)
+		app.AddWrapperFunc(count)
+		app.AddRoute("/foo").Get().Handler(paramHandler).WrapHandlerFunc(count)
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/foo", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, 3, counter)
+	})
+	t.Run("Conflicts", func(t *testing.T) {
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.NoVersions = true
+		app.AddRoute("/foo/{id}").Get().Handler(paramHandler)
+		app.AddRoute("/foo/{name}").Get().Handler(paramHandler)
+
+		assert.Error(t, app.Resolve())
+	})
+	t.Run("Merged", func(t *testing.T) {
+		counter := 0
+		app := NewApp().SetRouter(RouterImplStdlib)
+		app.SetPrefix("one")
+		app.AddMiddlewareFunc(func(next http.HandlerFunc) http.HandlerFunc {
+			return func(rw http.ResponseWriter, r *http.Request) {
+				counter++
+				next(rw, r)
+			}
+		})
+		app.AddRoute("/foo/{id}").Version(1).Get().Handler(paramHandler)
+
+		appTwo := NewApp().SetRouter(RouterImplStdlib)
+		appTwo.AddRoute("/foo/{id}").Version(1).Get().Handler(paramHandler)
+
+		h, err := MergeApplications(app, appTwo)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/one/v1/foo/bar", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "bar", rw.Body.String())
+		assert.Equal(t, 1, counter)
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/foo/baz", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "baz", rw.Body.String())
+		assert.Equal(t, 1, counter)
+	})
+}
@@ -0,0 +1,189 @@
+package gimlet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordingAdapter wraps the standard library adapter, recording
+// registered routes and modifying URL parameters, to ensure that
+// gimlet uses custom adapters for all routing operations.
+type recordingAdapter struct {
+	RouterAdapter
+	routes  *[]RouteDefinition
+	mounted []string
+}
+
+func (a *recordingAdapter) AddRoute(def RouteDefinition) error {
+	// only record application routes, not the routes that
+	// answer for unregistered methods.
+	if len(def.Methods) > 0 {
+		*a.routes = append(*a.routes, def)
+	}
+	return a.RouterAdapter.AddRoute(def)
+}
+
+func (a *recordingAdapter) Mount(prefix string, mws MiddlewareStack) (RouterAdapter, error) {
+	a.mounted = append(a.mounted, prefix)
+	sub, err := a.RouterAdapter.Mount(prefix, mws)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingAdapter{RouterAdapter: sub, routes: a.routes}, nil
+}
+
+func (a *recordingAdapter) Param(r *http.Request, key string) string {
+	return strings.ToUpper(a.RouterAdapter.Param(r, key))
+}
+
+func TestRouterRegistry(t *testing.T) {
+	t.Run("BuiltIn", func(t *testing.T) {
+		for impl, name := range map[RouterImplementation]string{
+			RouterImplGorilla: "gorilla",
+			RouterImplChi:     "chi",
+			RouterImplStdlib:  "stdlib",
+		} {
+			assert.Equal(t, name, impl.String())
+			assert.NoError(t, impl.Validate())
+		}
+
+		assert.Equal(t, "<undefined>", RouterImplementation(RouterImplUndefined).String())
+		assert.Error(t, RouterImplementation(RouterImplUndefined).Validate())
+		assert.Equal(t, "<invalid-router>", RouterImplementation(-1).String())
+		assert.Error(t, RouterImplementation(-1).Validate())
+		assert.Equal(t, "<invalid-router>", RouterImplementation(1024).String())
+		assert.Error(t, RouterImplementation(1024).Validate())
+	})
+	t.Run("RegistrationErrors", func(t *testing.T) {
+		impl, err := RegisterRouter("", newStdlibAdapter)
+		assert.Error(t, err)
+		assert.EqualValues(t, RouterImplUndefined, impl)
+
+		impl, err = RegisterRouter("nil-factory", nil)
+		assert.Error(t, err)
+		assert.EqualValues(t, RouterImplUndefined, impl)
+
+		impl, err = RegisterRouter("gorilla", newStdlibAdapter)
+		assert.Error(t, err)
+		assert.EqualValues(t, RouterImplUndefined, impl)
+	})
+	t.Run("NilAdapter", func(t *testing.T) {
+		impl, err := RegisterRouter("test-nil-adapter", func(RouterOptions) RouterAdapter { return nil })
+		require.NoError(t, err)
+		assert.NoError(t, impl.Validate())
+
+		app := NewApp().SetRouter(impl)
+		assert.Error(t, app.Resolve())
+	})
+}
+
+func TestCustomRouterAdapter(t *testing.T) {
+	var routes []RouteDefinition
+	impl, err := RegisterRouter("test-recording", func(opts RouterOptions) RouterAdapter {
+		return &recordingAdapter{RouterAdapter: newStdlibAdapter(opts), routes: &routes}
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "test-recording", impl.String())
+
+	handler := func(rw http.ResponseWriter, r *http.Request) { WriteText(rw, GetParam(r, "id")) }
+
+	t.Run("Application", func(t *testing.T) {
+		routes = nil
+		app := NewApp().SetRouter(impl)
+		app.SetPrefix("api")
+		app.AddRoute("/foo/{id}").Version(1).Get().Post().Handler(handler).Wrap(MakeRecoveryLogger())
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		require.Len(t, routes, 1)
+		assert.Equal(t, "/api/v1/foo/{id}", routes[0].Path)
+		assert.Equal(t, []string{"GET", "POST"}, routes[0].Methods)
+		assert.Len(t, routes[0].Middleware, 1)
+		assert.True(t, routes[0].StrictSlash)
+		assert.False(t, routes[0].IsPrefix)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v1/foo/bar", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "BAR", rw.Body.String())
+	})
+	t.Run("Merged", func(t *testing.T) {
+		routes = nil
+		app := NewApp().SetRouter(impl)
+		app.SetPrefix("one")
+		app.AddRoute("/foo/{id}").Version(1).Get().Handler(handler)
+
+		appTwo := NewApp().SetRouter(impl)
+		appTwo.AddRoute("/bar/{id}").Version(2).Get().Handler(handler)
+
+		h, err := MergeApplications(app, appTwo)
+		require.NoError(t, err)
+		require.Len(t, routes, 2)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/one/v1/foo/baz", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "BAZ", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v2/bar/qux", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "QUX", rw.Body.String())
+	})
+	t.Run("AssembleHandlerAdapter", func(t *testing.T) {
+		routes = nil
+		app := NewApp()
+		app.SetPrefix("one")
+		app.AddRoute("/foo/{id}").Version(1).Get().Handler(handler)
+
+		router := &recordingAdapter{RouterAdapter: newStdlibAdapter(RouterOptions{}), routes: &routes}
+		h, err := AssembleHandlerAdapter(router, app)
+		require.NoError(t, err)
+		assert.NotNil(t, h)
+		assert.Equal(t, []string{"/one"}, router.mounted)
+		require.Len(t, routes, 1)
+		assert.Equal(t, "/v1/foo/{id}", routes[0].Path)
+
+		h, err = AssembleHandlerAdapter(nil, app)
+		assert.Error(t, err)
+		assert.Nil(t, h)
+	})
+}
+
+func TestRouterAdapterParams(t *testing.T) {
+	for name, impl := range map[string]RouterImplementation{
+		"Gorilla": RouterImplGorilla,
+		"Chi":     RouterImplChi,
+		"Stdlib":  RouterImplStdlib,
+	} {
+		t.Run(name, func(t *testing.T) {
+			app := NewApp().SetRouter(impl)
+			app.NoVersions = true
+			app.AddRoute("/foo/{id}").Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+				WriteText(rw, GetParam(r, "id"))
+			})
+			app.AddPrefixRoute("/prefix").Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+				WriteText(rw, r.URL.Path)
+			})
+
+			h, err := app.Handler()
+			require.NoError(t, err)
+
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/foo/bar", nil))
+			assert.Equal(t, http.StatusOK, rw.Code)
+			assert.Equal(t, "bar", rw.Body.String())
+
+			rw = httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/prefix/under/path", nil))
+			assert.Equal(t, http.StatusOK, rw.Code)
+			assert.Equal(t, "/prefix/under/path", rw.Body.String())
+		})
+	}
+}
@@ -13,13 +13,22 @@ import (
 // route.
 type APIRoute struct {
 	route             string
+	name              string
 	prefix            string
 	methods           []httpMethod
 	handler           http.HandlerFunc
 	wrappers          []interface{}
 	version           int
+	lastVersion       int
+	latest            bool
+	latestAlias       bool
+	deprecation       *RouteDeprecation
+	maxRequestSize    int64
+	etagMode          ETagMode
+	problemDetails    bool
 	overrideAppPrefix bool
 	isPrefix          bool
+	doc               routeDocumentation
 }
 
 func (r *APIRoute) String() string {
@@ -180,6 +189,7 @@ func (r *APIRoute) Version(version int) *APIRoute {
 	}
 
 	r.version = version
+	r.lastVersion = 0
 	return r
 }
 
@@ -303,6 +313,8 @@ func (r *APIRoute) Method(m string) *APIRoute {
 		return r.Patch()
 	case head.String():
 		return r.Head()
+	case options.String():
+		return r.Options()
 	default:
 		return r
 	}
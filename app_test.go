@@ -31,6 +31,12 @@ func TestGorilaAppSuite(t *testing.T) {
 	suite.Run(t, s)
 }
 
+func TestStdlibAppSuite(t *testing.T) {
+	s := &AppSuite{}
+	s.constructor = func() *APIApp { return NewApp().SetRouter(RouterImplStdlib) }
+	suite.Run(t, s)
+}
+
 func TestDefaultAppSuite(t *testing.T) {
 	s := &AppSuite{}
 	s.constructor = NewApp
@@ -105,6 +111,10 @@ func (s *AppSuite) TestRouterReturnsRouterInstanceWhenResolved() {
 		r, err := s.app.Router()
 		s.Nil(r)
 		s.Error(err)
+	case RouterImplStdlib:
+		r, err := s.app.ServeMux()
+		s.Nil(r)
+		s.Error(err)
 	default:
 		s.T().Fatal("unsupported router")
 	}
@@ -122,9 +132,32 @@ func (s *AppSuite) TestRouterReturnsRouterInstanceWhenResolved() {
 		r, err := s.app.Router()
 		s.NotNil(r)
 		s.NoError(err)
+	case RouterImplStdlib:
+		r, err := s.app.ServeMux()
+		s.NotNil(r)
+		s.NoError(err)
+	default:
+		s.T().Fatal("unsupported router")
+	}
+}
+
+func (s *AppSuite) TestRouterGettersErrorForOtherAdapters() {
+	s.app.AddRoute("/foo").Version(1).Get().Handler(func(_ http.ResponseWriter, _ *http.Request) {})
+	s.NoError(s.app.Resolve())
+	s.app.adapter = &recordingAdapter{RouterAdapter: s.app.adapter}
+
+	var err error
+	switch s.app.routerImpl {
+	case RouterImplChi:
+		_, err = s.app.Mux()
+	case RouterImplGorilla:
+		_, err = s.app.Router()
+	case RouterImplStdlib:
+		_, err = s.app.ServeMux()
 	default:
 		s.T().Fatal("unsupported router")
 	}
+	s.Error(err)
 }
 
 func (s *AppSuite) TestResolveEncountersErrorsWithAnInvalidRoot() {
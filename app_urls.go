@@ -0,0 +1,167 @@
+package gimlet
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Name sets the name of the route, which you can pass to APIApp.URL
+// or GetURL to produce the path for the route. Names must be unique
+// within an application.
+func (r *APIRoute) Name(name string) *APIRoute {
+	r.name = name
+	return r
+}
+
+// URL returns the path for the named route as the application's
+// Handler serves it, including the application's prefix, the
+// version segment, and the route's prefix. Pass values for the
+// route's parameters as key value pairs, as in:
+//
+//	app.URL("widget", "id", "42")
+//
+// Values are escaped, and must match any pattern constraint in the
+// route definition (e.g. "{id:[0-9]+}").
+func (a *APIApp) URL(name string, params ...string) (string, error) {
+	return (&urlResolver{app: a, addAppPrefix: true}).URL(name, params...)
+}
+
+// GetURL returns the path for a named route within a handler, using
+// the application that registered the handler's route. Paths
+// reflect how the application was resolved: for applications
+// combined with MergeApplications, the path includes the mount
+// prefix of the application.
+func GetURL(ctx context.Context, name string, params ...string) (string, error) {
+	if rv := ctx.Value(urlResolverKey); rv != nil {
+		if urls, ok := rv.(*urlResolver); ok {
+			return urls.URL(name, params...)
+		}
+	}
+
+	return "", errors.New("request does not have an application in its context")
+}
+
+type urlResolver struct {
+	app          *APIApp
+	addAppPrefix bool
+	mount        string
+}
+
+func (u *urlResolver) URL(name string, params ...string) (string, error) {
+	if len(params)%2 != 0 {
+		return "", errors.Errorf("parameters for route '%s' must be key value pairs", name)
+	}
+
+	values := make(map[string]string, len(params)/2)
+	for i := 0; i < len(params); i += 2 {
+		values[params[i]] = params[i+1]
+	}
+
+	for _, route := range u.app.routes {
+		if route.name != name {
+			continue
+		}
+
+		// routes with version ranges resolve to the highest
+		// version of the route.
+		versions := route.expand()
+		if route.latest && len(versions) > 1 {
+			versions = versions[:len(versions)-1]
+		}
+
+		path, err := versions[len(versions)-1].resolvePath(u.app, u.addAppPrefix)
+		if err != nil {
+			return "", errors.Wrapf(err, "problem resolving route '%s'", name)
+		}
+
+		path, err = expandPath(u.mount+path, values)
+		return path, errors.Wrapf(err, "problem building url for route '%s'", name)
+	}
+
+	return "", errors.Errorf("no route named '%s'", name)
+}
+
+// expandPath replaces the variables in a route's path (e.g. "{id}",
+// "{id:[0-9]+}", or "{path...}") with the values, checking values
+// against any constraints. It is an error to omit a variable, or to
+// pass a value that the route does not use.
+func expandPath(path string, values map[string]string) (string, error) {
+	var (
+		out   strings.Builder
+		used  = map[string]struct{}{}
+		start = -1
+		depth int
+	)
+
+	for idx, c := range path {
+		switch {
+		case c == '{':
+			if depth == 0 {
+				start = idx
+			}
+			depth++
+			continue
+		case c == '}' && depth > 0:
+			depth--
+			if depth > 0 {
+				continue
+			}
+		default:
+			if depth == 0 {
+				out.WriteRune(c)
+			}
+			continue
+		}
+
+		name, expr, hasExpr := strings.Cut(path[start+1:idx], ":")
+		name = strings.TrimSpace(name)
+		if name == "$" {
+			continue
+		}
+
+		wildcard := strings.HasSuffix(name, "...")
+		name = strings.TrimSuffix(name, "...")
+
+		value, ok := values[name]
+		if !ok {
+			return "", errors.Errorf("missing value for parameter '%s'", name)
+		}
+		used[name] = struct{}{}
+
+		if hasExpr {
+			re, err := regexp.Compile("^(?:" + expr + ")$")
+			if err != nil {
+				return "", errors.Wrapf(err, "invalid pattern for parameter '%s'", name)
+			}
+			if !re.MatchString(value) {
+				return "", errors.Errorf("value '%s' for parameter '%s' does not match '%s'", value, name, expr)
+			}
+		}
+
+		if wildcard {
+			segments := strings.Split(value, "/")
+			for i := range segments {
+				segments[i] = url.PathEscape(segments[i])
+			}
+			out.WriteString(strings.Join(segments, "/"))
+		} else {
+			out.WriteString(url.PathEscape(value))
+		}
+	}
+
+	if depth != 0 {
+		return "", errors.Errorf("path '%s' has unbalanced braces", path)
+	}
+
+	for name := range values {
+		if _, ok := used[name]; !ok {
+			return "", errors.Errorf("route does not have a parameter named '%s'", name)
+		}
+	}
+
+	return out.String(), nil
+}
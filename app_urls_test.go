@@ -0,0 +1,112 @@
+package gimlet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteURLs(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+
+	t.Run("Resolution", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("api")
+		app.AddRoute("/widgets/{id:[0-9]+}").Version(2).Get().Handler(noop).Name("widget")
+		app.AddPrefixRoute("/files/{path...}").Prefix("/admin").Version(1).Get().Handler(noop).Name("files")
+		app.AddRoute("/override").Prefix("/other").OverridePrefix().Version(1).Get().Handler(noop).Name("override")
+		app.AddRoute("/unversioned").Get().Handler(noop).Name("unversioned")
+
+		path, err := app.URL("widget", "id", "42")
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v2/widgets/42", path)
+
+		path, err = app.URL("files", "path", "a b/c")
+		require.NoError(t, err)
+		assert.Equal(t, "/api/v1/admin/files/a%20b/c", path)
+
+		path, err = app.URL("override")
+		require.NoError(t, err)
+		assert.Equal(t, "/other/v1/override", path)
+
+		app.SimpleVersions = true
+		path, err = app.URL("widget", "id", "42")
+		require.NoError(t, err)
+		assert.Equal(t, "/api/2/widgets/42", path)
+
+		_, err = app.URL("widget", "id", "forty-two")
+		assert.Error(t, err)
+		_, err = app.URL("widget")
+		assert.Error(t, err)
+		_, err = app.URL("widget", "id")
+		assert.Error(t, err)
+		_, err = app.URL("widget", "id", "42", "other", "value")
+		assert.Error(t, err)
+		_, err = app.URL("unversioned")
+		assert.Error(t, err)
+		_, err = app.URL("missing")
+		assert.Error(t, err)
+	})
+	t.Run("DuplicateNames", func(t *testing.T) {
+		app := NewApp()
+		app.AddRoute("/one").Version(1).Get().Handler(noop).Name("dupe")
+		app.AddRoute("/two").Version(1).Get().Handler(noop).Name("dupe")
+		assert.Error(t, app.Resolve())
+	})
+	t.Run("Context", func(t *testing.T) {
+		_, err := GetURL(httptest.NewRequest(http.MethodGet, "/", nil).Context(), "widget")
+		assert.Error(t, err)
+
+		for name, impl := range map[string]RouterImplementation{
+			"Gorilla": RouterImplGorilla,
+			"Chi":     RouterImplChi,
+			"Stdlib":  RouterImplStdlib,
+		} {
+			t.Run(name, func(t *testing.T) {
+				app := NewApp()
+				app.SetRouter(impl)
+				app.SetPrefix("api")
+				app.AddRoute("/widgets/{id}").Version(1).Get().Name("widget").Handler(func(rw http.ResponseWriter, r *http.Request) {
+					path, err := GetURL(r.Context(), "widget", "id", GetParam(r, "id")+"0")
+					if err != nil {
+						WriteTextInternalError(rw, err.Error())
+						return
+					}
+					WriteText(rw, path)
+				})
+
+				h, err := app.Handler()
+				require.NoError(t, err)
+
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/api/v1/widgets/4", nil))
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "/api/v1/widgets/40", rw.Body.String())
+			})
+		}
+	})
+	t.Run("MergedApplications", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("one")
+		app.AddRoute("/foo").Version(1).Get().Name("foo").Handler(func(rw http.ResponseWriter, r *http.Request) {
+			path, _ := GetURL(r.Context(), "foo")
+			WriteText(rw, path)
+		})
+
+		h, err := MergeApplications(app)
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/one/v1/foo", nil))
+		assert.Equal(t, "/one/v1/foo", rw.Body.String())
+
+		root := NewApp()
+		require.NoError(t, root.Merge(app))
+		path, err := root.URL("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "/v1/one/foo", path)
+	})
+}
@@ -0,0 +1,310 @@
+package gimlet
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// VersioningStrategy describes how an application determines the
+// version of the route that handles a request. Strategies are flags,
+// and you can combine them (e.g. "VersionByPath|VersionByHeader") to
+// accept versions from more than one source.
+type VersioningStrategy int
+
+const (
+	// VersionByPath routes requests using a version segment in the
+	// path (e.g. "/v1/foo"), and is the default strategy.
+	VersionByPath VersioningStrategy = 1 << iota
+
+	// VersionByHeader routes requests for unversioned paths
+	// (e.g. "/foo") using a header (e.g. "API-Version: 2").
+	VersionByHeader
+
+	// VersionByMediaType routes requests for unversioned paths
+	// using a vendor media type in the Accept header
+	// (e.g. "application/vnd.example.v2+json").
+	VersionByMediaType
+)
+
+// VersioningOptions configures how the application routes versioned
+// routes. See APIApp.SetVersioning.
+type VersioningOptions struct {
+	// Strategy determines which parts of the request specify
+	// versions. The zero value uses VersionByPath.
+	Strategy VersioningStrategy
+
+	// Header is the name of the header for the VersionByHeader
+	// strategy, and defaults to "API-Version". Values may have a
+	// "v" prefix (e.g. "2" or "v2"), or may be "latest" to select
+	// the highest version of the route.
+	Header string
+
+	// Vendor, if specified, restricts the VersionByMediaType
+	// strategy to media types for the vendor
+	// (e.g. "application/vnd.<vendor>.v2+json"), otherwise media
+	// types for any vendor specify versions.
+	Vendor string
+
+	// DefaultVersion is the version of the route that handles
+	// requests to unversioned paths that do not specify a
+	// version.
+	DefaultVersion int
+
+	// RequireVersion rejects requests to unversioned paths that
+	// do not specify a version, rather than using the default.
+	RequireVersion bool
+}
+
+// Validate checks the options, and sets the default header.
+func (o *VersioningOptions) Validate() error {
+	if o.Strategy == 0 {
+		o.Strategy = VersionByPath
+	}
+
+	if o.Strategy&^(VersionByPath|VersionByHeader|VersionByMediaType) != 0 {
+		return errors.Errorf("%d is not a valid versioning strategy", o.Strategy)
+	}
+
+	if o.Header == "" {
+		o.Header = "API-Version"
+	}
+
+	if o.DefaultVersion < 0 {
+		return errors.Errorf("%d is not a valid default version", o.DefaultVersion)
+	}
+
+	return nil
+}
+
+// SetVersioning configures how the application routes requests to
+// versioned routes. When the strategy includes VersionByHeader or
+// VersionByMediaType, routes that share a path and method but have
+// different versions are served from a single unversioned path
+// (e.g. "/prefix/foo"), and gimlet dispatches each request to the
+// route for the version the request specifies.
+func (a *APIApp) SetVersioning(opts VersioningOptions) error {
+	if a.isResolved {
+		return errors.New("cannot set versioning after resolving the application")
+	}
+
+	if err := opts.Validate(); err != nil {
+		return errors.WithStack(err)
+	}
+
+	a.versioning = opts
+	return nil
+}
+
+func (o VersioningOptions) byPath() bool {
+	return o.Strategy == 0 || o.Strategy&VersionByPath != 0
+}
+
+func (o VersioningOptions) negotiated() bool {
+	return o.Strategy&(VersionByHeader|VersionByMediaType) != 0
+}
+
+// GetVersion returns the version of the route handling the request,
+// for routes with versions.
+func GetVersion(ctx context.Context) (int, bool) {
+	if rv := ctx.Value(versionKey); rv != nil {
+		if v, ok := rv.(int); ok {
+			return v, true
+		}
+	}
+
+	return -1, false
+}
+
+func withVersion(version int, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), versionKey, version)))
+	})
+}
+
+var mediaTypeVersionPattern = regexp.MustCompile(`^application/vnd\.(.+)\.v([0-9]+)(?:\+[a-z0-9.-]+)?$`)
+
+func parseVersion(value string) (int, error) {
+	v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(value)), "v"))
+	if err != nil || v < 0 {
+		return -1, errors.Errorf("'%s' is not a valid version", value)
+	}
+
+	return v, nil
+}
+
+// mediaTypeVersion returns the version from the preferred versioned
+// media type in the Accept header, or -1 if the header does not
+// specify a versioned media type.
+func (o VersioningOptions) mediaTypeVersion(r *http.Request) (int, error) {
+	var (
+		version = -1
+		quality = 0.0
+	)
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, value := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+
+			match := mediaTypeVersionPattern.FindStringSubmatch(mediaType)
+			if match == nil || (o.Vendor != "" && match[1] != o.Vendor) {
+				continue
+			}
+
+			q := 1.0
+			if qv, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qv, 64); err != nil {
+					return -1, errors.Errorf("'%s' is not a valid quality for %s", qv, mediaType)
+				}
+			}
+
+			if q > quality {
+				v, err := parseVersion(match[2])
+				if err != nil {
+					return -1, err
+				}
+				version, quality = v, q
+			}
+		}
+	}
+
+	return version, nil
+}
+
+// versionedRoutes collects the handlers for routes that share a path
+// and method, so that applications that negotiate versions can
+// register a single route that dispatches to the handler for the
+// requested version.
+type versionedRoutes struct {
+	order  []versionedRouteKey
+	routes map[versionedRouteKey]*versionDispatcher
+}
+
+type versionedRouteKey struct {
+	path     string
+	isPrefix bool
+	method   string
+}
+
+func (v *versionedRoutes) add(app *APIApp, path string, isPrefix bool, method string, version int, handler http.Handler) error {
+	if v.routes == nil {
+		v.routes = map[versionedRouteKey]*versionDispatcher{}
+	}
+
+	key := versionedRouteKey{path: path, isPrefix: isPrefix, method: method}
+	if _, ok := v.routes[key]; !ok {
+		v.order = append(v.order, key)
+		v.routes[key] = &versionDispatcher{
+			opts:        app.versioning,
+			strictSlash: app.StrictSlash,
+			handlers:    map[int]http.Handler{},
+		}
+	}
+
+	if _, ok := v.routes[key].handlers[version]; ok {
+		return errors.Errorf("version %d of '%s %s' is defined more than once", version, method, path)
+	}
+
+	v.routes[key].handlers[version] = handler
+	return nil
+}
+
+func (v *versionedRoutes) attach(router RouterAdapter) error {
+	for _, key := range v.order {
+		if err := router.AddRoute(RouteDefinition{
+			Path:        key.path,
+			Methods:     []string{key.method},
+			IsPrefix:    key.isPrefix,
+			StrictSlash: v.routes[key].strictSlash,
+			Handler:     v.routes[key],
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type versionDispatcher struct {
+	opts        VersioningOptions
+	strictSlash bool
+	handlers    map[int]http.Handler
+}
+
+func (d *versionDispatcher) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
+	version, code, err := d.requestVersion(r)
+	if err != nil {
+		WriteJSONResponse(rw, code, ErrorResponse{StatusCode: code, Message: err.Error()})
+		return
+	}
+
+	handler, ok := d.handlers[version]
+	if !ok {
+		WriteJSONResponse(rw, code, ErrorResponse{
+			StatusCode: code,
+			Message:    fmt.Sprintf("version %d is not supported for %s %s", version, r.Method, r.URL.Path),
+		})
+		return
+	}
+
+	handler.ServeHTTP(rw, r)
+}
+
+func (d *versionDispatcher) latestVersion() int {
+	latest := -1
+	for v := range d.handlers {
+		if v > latest {
+			latest = v
+		}
+	}
+	return latest
+}
+
+// requestVersion returns the version for the request, and the status
+// code for responses if the request's version is not valid or there
+// is no handler for the version.
+func (d *versionDispatcher) requestVersion(r *http.Request) (int, int, error) {
+	version, code := -1, http.StatusBadRequest
+
+	if d.opts.Strategy&VersionByHeader != 0 {
+		if value := r.Header.Get(d.opts.Header); strings.EqualFold(value, latestVersionAlias) {
+			version = d.latestVersion()
+		} else if value != "" {
+			v, err := parseVersion(value)
+			if err != nil {
+				return -1, http.StatusBadRequest, errors.Wrapf(err, "invalid %s header", d.opts.Header)
+			}
+			version = v
+		}
+	}
+
+	if d.opts.Strategy&VersionByMediaType != 0 {
+		v, err := d.opts.mediaTypeVersion(r)
+		switch {
+		case err != nil:
+			return -1, http.StatusBadRequest, errors.Wrap(err, "invalid Accept header")
+		case v >= 0 && version >= 0 && v != version:
+			return -1, http.StatusBadRequest, errors.Errorf("%s header and Accept header specify different versions", d.opts.Header)
+		case v >= 0:
+			version, code = v, http.StatusNotAcceptable
+		}
+	}
+
+	if version < 0 {
+		if d.opts.RequireVersion {
+			return -1, http.StatusBadRequest, errors.New("request does not specify a version")
+		}
+		version, code = d.opts.DefaultVersion, http.StatusNotAcceptable
+	}
+
+	return version, code, nil
+}
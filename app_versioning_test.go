@@ -0,0 +1,172 @@
+package gimlet
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVersioningOptions(t *testing.T) {
+	opts := VersioningOptions{}
+	require.NoError(t, opts.Validate())
+	assert.Equal(t, VersionByPath, opts.Strategy)
+	assert.Equal(t, "API-Version", opts.Header)
+
+	opts = VersioningOptions{Strategy: 1 << 5}
+	assert.Error(t, opts.Validate())
+
+	opts = VersioningOptions{DefaultVersion: -1}
+	assert.Error(t, opts.Validate())
+
+	app := NewApp()
+	require.NoError(t, app.Resolve())
+	assert.Error(t, app.SetVersioning(VersioningOptions{}))
+}
+
+func TestVersionNegotiation(t *testing.T) {
+	versioned := func(rw http.ResponseWriter, r *http.Request) {
+		v, ok := GetVersion(r.Context())
+		if !ok {
+			WriteTextInternalError(rw, "no version")
+			return
+		}
+		WriteText(rw, fmt.Sprint(v))
+	}
+
+	for name, impl := range map[string]RouterImplementation{
+		"Gorilla": RouterImplGorilla,
+		"Chi":     RouterImplChi,
+		"Stdlib":  RouterImplStdlib,
+	} {
+		t.Run(name, func(t *testing.T) {
+			newApp := func(t *testing.T, opts VersioningOptions) http.Handler {
+				app := NewApp()
+				app.SetRouter(impl)
+				app.SetPrefix("api")
+				require.NoError(t, app.SetVersioning(opts))
+				app.AddRoute("/foo/{id}").Version(1).Get().Handler(versioned)
+				app.AddRoute("/foo/{id}").Version(2).Get().Post().Handler(versioned)
+
+				h, err := app.Handler()
+				require.NoError(t, err)
+				return h
+			}
+
+			request := func(h http.Handler, method, path string, headers ...string) *httptest.ResponseRecorder {
+				req := httptest.NewRequest(method, path, nil)
+				for i := 0; i+1 < len(headers); i += 2 {
+					req.Header.Add(headers[i], headers[i+1])
+				}
+				rw := httptest.NewRecorder()
+				h.ServeHTTP(rw, req)
+				return rw
+			}
+
+			t.Run("Path", func(t *testing.T) {
+				h := newApp(t, VersioningOptions{})
+
+				rw := request(h, http.MethodGet, "/api/v2/foo/1")
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "2", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "API-Version", "2")
+				assert.Equal(t, http.StatusNotFound, rw.Code)
+			})
+			t.Run("Header", func(t *testing.T) {
+				h := newApp(t, VersioningOptions{Strategy: VersionByHeader, DefaultVersion: 1})
+
+				rw := request(h, http.MethodGet, "/api/foo/1", "API-Version", "2")
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "2", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "API-Version", "v1")
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1")
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "API-Version", "3")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "API-Version", "latest")
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "2", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "API-Version", "newest")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+				rw = request(h, http.MethodPost, "/api/foo/1", "API-Version", "1")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+				rw = request(h, http.MethodDelete, "/api/foo/1")
+				assert.Equal(t, http.StatusMethodNotAllowed, rw.Code)
+				assert.Equal(t, "GET, OPTIONS, POST", rw.Header().Get("Allow"))
+
+				rw = request(h, http.MethodGet, "/api/v1/foo/1")
+				assert.Equal(t, http.StatusNotFound, rw.Code)
+			})
+			t.Run("MediaType", func(t *testing.T) {
+				h := newApp(t, VersioningOptions{Strategy: VersionByMediaType, Vendor: "example", RequireVersion: true})
+
+				rw := request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.example.v2+json")
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, "2", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.example.v2+json;q=0.5, application/vnd.example.v1+json")
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.example.v3+json")
+				assert.Equal(t, http.StatusNotAcceptable, rw.Code)
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.other.v2+json")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+
+				rw = request(h, http.MethodGet, "/api/foo/1")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+			})
+			t.Run("Combined", func(t *testing.T) {
+				h := newApp(t, VersioningOptions{
+					Strategy:       VersionByPath | VersionByHeader | VersionByMediaType,
+					DefaultVersion: 2,
+				})
+
+				rw := request(h, http.MethodGet, "/api/v1/foo/1")
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1")
+				assert.Equal(t, "2", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.any.v1+json")
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.any.v1+json", "API-Version", "1")
+				assert.Equal(t, "1", rw.Body.String())
+
+				rw = request(h, http.MethodGet, "/api/foo/1", "Accept", "application/vnd.any.v1+json", "API-Version", "2")
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+			})
+		})
+	}
+	t.Run("DuplicateVersions", func(t *testing.T) {
+		app := NewApp()
+		require.NoError(t, app.SetVersioning(VersioningOptions{Strategy: VersionByHeader}))
+		app.AddRoute("/foo").Version(1).Get().Handler(versioned)
+		app.AddRoute("/foo").Version(1).Get().Handler(versioned)
+		assert.Error(t, app.Resolve())
+	})
+	t.Run("URL", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("api")
+		require.NoError(t, app.SetVersioning(VersioningOptions{Strategy: VersionByHeader}))
+		app.AddRoute("/foo").Version(1).Get().Handler(versioned).Name("foo")
+
+		path, err := app.URL("foo")
+		require.NoError(t, err)
+		assert.Equal(t, "/api/foo", path)
+	})
+}
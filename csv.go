@@ -3,8 +3,10 @@ package gimlet
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"net/http"
 	"reflect"
+	"strings"
 
 	"github.com/pkg/errors"
 )
@@ -28,6 +30,26 @@ func getCSVFields(t reflect.Type) []string {
 	return fields
 }
 
+// getCSVFieldIndexes returns the index sequences (as used by
+// reflect.Value.FieldByIndex) of the fields that getCSVFields names,
+// in the same order.
+func getCSVFieldIndexes(t reflect.Type, prefix []int) [][]int {
+	indexes := [][]int{}
+	numberFields := t.NumField()
+	for i := 0; i < numberFields; i++ {
+		index := append(append([]int{}, prefix...), i)
+		fieldType := t.Field(i).Type
+		if fieldType.Kind() == reflect.Struct && fieldType.NumField() > 0 {
+			indexes = append(indexes, getCSVFieldIndexes(fieldType, index)...)
+			continue
+		}
+		if t.Field(i).Tag.Get("csv") != "" {
+			indexes = append(indexes, index)
+		}
+	}
+	return indexes
+}
+
 // getCSVValues takes in a struct and returns a string of values based on struct tags
 func getCSVValues(data interface{}) []string {
 	values := []string{}
@@ -114,3 +136,144 @@ func WriteCSVInternalError(w http.ResponseWriter, data interface{}) {
 	// 500
 	WriteCSVResponse(w, http.StatusInternalServerError, data)
 }
+
+// GetCSV parses CSV from a io.ReadCloser (e.g. http/*Request.Body)
+// into the slice of structs (or pointers to structs) that data points
+// to, as the inverse of WriteCSVResponse. The first row of the
+// document is a header: GetCSV sets the fields whose csv struct tags
+// match the header's columns, including the fields of nested structs,
+// and ignores other columns. When several fields share a tag, as with
+// nested structs of the same type, columns with that name map to the
+// fields in order.
+//
+// Values convert to the types of fields as with BindParams, and empty
+// values leave fields unset. If any value is invalid, GetCSV returns
+// an ErrorResponse with a 400 status, which lists the row and column
+// of every failure.
+//
+// If the body is greater than 16 megabytes in size, GetCSV returns an
+// ErrorResponse with a 413 status.
+func GetCSV(r io.ReadCloser, data interface{}) error {
+	if r == nil {
+		return errors.New("no data defined")
+	}
+	defer r.Close()
+
+	val := reflect.ValueOf(data)
+	elemType, err := getCSVElemType(val)
+	if err != nil {
+		return errors.Wrapf(err, "cannot decode CSV into %T", data)
+	}
+
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	reader := csv.NewReader(http.MaxBytesReader(nil, r, maxRequestSize))
+	header, err := reader.Read()
+	if err == io.EOF {
+		val.Elem().Set(reflect.MakeSlice(val.Elem().Type(), 0, 0))
+		return nil
+	}
+	if err != nil {
+		return csvReadError(err)
+	}
+
+	available := map[string][][]int{}
+	names := getCSVFields(structType)
+	for idx, index := range getCSVFieldIndexes(structType, nil) {
+		available[names[idx]] = append(available[names[idx]], index)
+	}
+
+	columns := make([][]int, len(header))
+	for idx, name := range header {
+		name = strings.TrimSpace(name)
+		if len(available[name]) > 0 {
+			columns[idx] = available[name][0]
+			available[name] = available[name][1:]
+		}
+	}
+
+	var failures []FieldError
+	out := reflect.MakeSlice(val.Elem().Type(), 0, 0)
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return csvReadError(err)
+		}
+
+		item := reflect.New(structType).Elem()
+		for idx, value := range record {
+			if columns[idx] == nil || value == "" {
+				continue
+			}
+
+			if err := setParam(item.FieldByIndex(columns[idx]), []string{value}); err != nil {
+				line, _ := reader.FieldPos(idx)
+				failures = append(failures, FieldError{Field: header[idx], Row: line, Message: err.Error()})
+			}
+		}
+
+		if elemType.Kind() == reflect.Ptr {
+			item = item.Addr()
+		}
+		out = reflect.Append(out, item)
+	}
+
+	if len(failures) > 0 {
+		msgs := make([]string, 0, len(failures))
+		for _, f := range failures {
+			msgs = append(msgs, f.Error())
+		}
+
+		return ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid CSV: %s", strings.Join(msgs, "; ")),
+			Fields:     failures,
+		}
+	}
+
+	val.Elem().Set(out)
+	return nil
+}
+
+// getCSVElemType returns the element type of the slice that val
+// points to, which must be a struct or a pointer to a struct.
+func getCSVElemType(val reflect.Value) (reflect.Type, error) {
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Slice {
+		return nil, errors.New("destination is not a pointer to a slice")
+	}
+
+	elemType := val.Elem().Type().Elem()
+	structType := elemType
+	if structType.Kind() == reflect.Ptr {
+		structType = structType.Elem()
+	}
+
+	if structType.Kind() != reflect.Struct {
+		return nil, errors.Errorf("%s is not a struct", elemType)
+	}
+
+	return elemType, nil
+}
+
+func csvReadError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return bodyError(err, "problem reading CSV")
+	}
+
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("invalid CSV: %s", parseErr),
+		}
+	}
+
+	return errors.WithStack(err)
+}
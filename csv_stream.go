@@ -0,0 +1,204 @@
+package gimlet
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"iter"
+	"net/http"
+	"reflect"
+)
+
+const defaultCSVFlushInterval = 100
+
+// CSVStreamOptions configures streaming CSV responders.
+type CSVStreamOptions struct {
+	// Delimiter separates the values in each row, and defaults to
+	// a comma. Streams with a tab delimiter use the content type
+	// for tab separated values.
+	Delimiter rune
+
+	// FlushInterval is the number of rows that the responder
+	// writes between flushing the response to the client, and
+	// defaults to 100.
+	FlushInterval int
+}
+
+// NewCSVStreamResponse constructs a StreamResponder that writes the
+// items of the sequence, which must be structs or pointers to
+// structs, as CSV rows. The header row, which the responder writes
+// before the first item, contains the csv struct tags of the type,
+// as with WriteCSVResponse. The responder stops writing at the first
+// error in the sequence.
+//
+// If the sequence produces an error before its first item, the
+// responder writes an error response with a 500 status. Otherwise,
+// the response has a 200 status, and the responder reports errors
+// in the X-Stream-Error trailer.
+func NewCSVStreamResponse[T any](items iter.Seq2[T, error], opts CSVStreamOptions) Responder {
+	var source interface{}
+	if items != nil {
+		source = items
+	}
+
+	return &csvStreamResponder[T]{
+		streamResponder: streamResponder{source: source, format: CSV, status: http.StatusOK},
+		items:           func(context.Context) iter.Seq2[T, error] { return items },
+		opts:            opts,
+	}
+}
+
+// NewCSVChannelResponse constructs a StreamResponder, as
+// NewCSVStreamResponse, that writes the items that it receives from
+// the channel until the channel is closed.
+func NewCSVChannelResponse[T any](items <-chan T, opts CSVStreamOptions) Responder {
+	var source interface{}
+	if items != nil {
+		source = items
+	}
+
+	return &csvStreamResponder[T]{
+		streamResponder: streamResponder{source: source, format: CSV, status: http.StatusOK},
+		items:           func(ctx context.Context) iter.Seq2[T, error] { return channelSeq(ctx, items) },
+		opts:            opts,
+	}
+}
+
+type csvStreamResponder[T any] struct {
+	streamResponder
+	items func(context.Context) iter.Seq2[T, error]
+	opts  CSVStreamOptions
+}
+
+func (r *csvStreamResponder[T]) Validate() error {
+	if _, err := csvStructType[T](); err != nil {
+		return err
+	}
+
+	return r.streamResponder.Validate()
+}
+
+func csvStructType[T any]() (reflect.Type, error) {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("cannot write %s values as CSV", t)
+	}
+
+	return t, nil
+}
+
+func (r *csvStreamResponder[T]) contentType() string {
+	if r.opts.Delimiter == '\t' {
+		return "text/tab-separated-values; charset=utf-8"
+	}
+
+	return CSV.ContentType()
+}
+
+func (r *csvStreamResponder[T]) newWriter(rw http.ResponseWriter) *csv.Writer {
+	cw := csv.NewWriter(rw)
+	if r.opts.Delimiter != 0 {
+		cw.Comma = r.opts.Delimiter
+	}
+	return cw
+}
+
+func (r *csvStreamResponder[T]) Stream(ctx context.Context, rw http.ResponseWriter) error {
+	structType, err := csvStructType[T]()
+	if err != nil {
+		return err
+	}
+
+	interval := r.opts.FlushInterval
+	if interval <= 0 {
+		interval = defaultCSVFlushInterval
+	}
+
+	var (
+		cw      *csv.Writer
+		started bool
+		rows    int
+	)
+
+	start := func() error {
+		started = true
+		rw.Header().Set("Content-Type", r.contentType())
+		rw.Header().Add("Trailer", StreamErrorTrailer)
+		rw.WriteHeader(r.status)
+
+		cw = r.newWriter(rw)
+		return cw.Write(getCSVFields(structType))
+	}
+
+	for item, err := range r.items(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			if !started {
+				r.writeError(rw, err)
+				return err
+			}
+
+			cw.Flush()
+			reportStreamError(rw, err)
+			return err
+		}
+
+		val := reflect.ValueOf(item)
+		if val.Kind() == reflect.Ptr {
+			if val.IsNil() {
+				continue
+			}
+			val = val.Elem()
+		}
+
+		if !started {
+			if err := start(); err != nil {
+				return err
+			}
+		}
+
+		if err := cw.Write(getCSVValues(val.Interface())); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%interval == 0 {
+			cw.Flush()
+			if err := cw.Error(); err != nil {
+				return err
+			}
+			if err := flush(rw); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !started {
+		if err := start(); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeError writes an error response for streams that fail before
+// writing any data.
+func (r *csvStreamResponder[T]) writeError(rw http.ResponseWriter, err error) {
+	eresp := streamErrorResponse(err)
+
+	rw.Header().Set("Content-Type", r.contentType())
+	rw.WriteHeader(eresp.StatusCode)
+
+	cw := r.newWriter(rw)
+	records, _ := convertDataToCSVRecord([]ErrorResponse{eresp})
+	_ = cw.WriteAll(records)
+}
@@ -0,0 +1,120 @@
+package gimlet
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleSeq(n int, failAt int) iter.Seq2[*SampleStruct, error] {
+	return func(yield func(*SampleStruct, error) bool) {
+		for i := 0; i < n; i++ {
+			if i == failAt {
+				yield(nil, errors.New("source failed"))
+				return
+			}
+			if !yield(&SampleStruct{FieldA: "row", FieldInt: i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+func TestCSVStreamResponder(t *testing.T) {
+	t.Run("Rows", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		resp := NewCSVStreamResponse(sampleSeq(250, -1), CSVStreamOptions{FlushInterval: 100})
+		require.NoError(t, resp.Validate())
+		WriteResponse(rw, resp)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, CSV.ContentType(), rw.Header().Get("Content-Type"))
+		assert.True(t, rw.Flushed)
+
+		lines := strings.Split(strings.TrimSpace(rw.Body.String()), "\n")
+		require.Len(t, lines, 251)
+		assert.Equal(t, "fieldA,fieldB,boolean,this,this", lines[0])
+		assert.Equal(t, "row,249,false,,", lines[250])
+		assert.Empty(t, rw.Result().Trailer.Get(StreamErrorTrailer))
+	})
+	t.Run("TSV", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, NewCSVStreamResponse(sampleSeq(1, -1), CSVStreamOptions{Delimiter: '\t'}))
+
+		assert.Equal(t, "text/tab-separated-values; charset=utf-8", rw.Header().Get("Content-Type"))
+		assert.Equal(t, "fieldA\tfieldB\tboolean\tthis\tthis\nrow\t0\tfalse\t\t\n", rw.Body.String())
+	})
+	t.Run("Empty", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, NewCSVStreamResponse(sampleSeq(0, -1), CSVStreamOptions{}))
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "fieldA,fieldB,boolean,this,this\n", rw.Body.String())
+	})
+	t.Run("MidStreamError", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		resp := NewCSVStreamResponse(sampleSeq(10, 2), CSVStreamOptions{}).(StreamResponder)
+		assert.NotPanics(t, func() {
+			assert.Error(t, resp.Stream(context.Background(), rw))
+		})
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Len(t, strings.Split(strings.TrimSpace(rw.Body.String()), "\n"), 3)
+		assert.Equal(t, "source failed", rw.Result().Trailer.Get(StreamErrorTrailer))
+	})
+	t.Run("InitialError", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		resp := NewCSVStreamResponse(sampleSeq(10, 0), CSVStreamOptions{}).(StreamResponder)
+		assert.Error(t, resp.Stream(context.Background(), rw))
+
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Contains(t, rw.Body.String(), "source failed")
+	})
+	t.Run("Channel", func(t *testing.T) {
+		ch := make(chan SampleStruct, 3)
+		for i := 0; i < 3; i++ {
+			ch <- SampleStruct{FieldA: "chan", FieldInt: i}
+		}
+		close(ch)
+
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, NewCSVChannelResponse(ch, CSVStreamOptions{}))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Len(t, strings.Split(strings.TrimSpace(rw.Body.String()), "\n"), 4)
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ch := make(chan SampleStruct, 1)
+		ch <- SampleStruct{FieldA: "chan"}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		resp := NewCSVChannelResponse(ch, CSVStreamOptions{}).(StreamResponder)
+
+		rw := httptest.NewRecorder()
+		done := make(chan error)
+		go func() { done <- resp.Stream(ctx, rw) }()
+		cancel()
+
+		assert.ErrorIs(t, <-done, context.Canceled)
+	})
+	t.Run("Responder", func(t *testing.T) {
+		resp := NewCSVStreamResponse(sampleSeq(1, -1), CSVStreamOptions{})
+		assert.Equal(t, CSV, resp.Format())
+		assert.NoError(t, resp.SetFormat(CSV))
+		assert.Error(t, resp.SetFormat(JSON))
+		assert.Error(t, resp.AddData(SampleStruct{}))
+		assert.NoError(t, resp.SetStatus(http.StatusPartialContent))
+		assert.Equal(t, http.StatusPartialContent, resp.Status())
+		assert.Error(t, resp.SetStatus(1))
+
+		assert.Error(t, NewCSVStreamResponse[SampleStruct](nil, CSVStreamOptions{}).Validate())
+		assert.Error(t, NewCSVChannelResponse[SampleStruct](nil, CSVStreamOptions{}).Validate())
+		assert.Error(t, NewCSVChannelResponse(make(chan string), CSVStreamOptions{}).Validate())
+	})
+}
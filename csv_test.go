@@ -1,9 +1,14 @@
 package gimlet
 
 import (
+	"bytes"
+	"io"
+	"net/http"
 	"net/http/httptest"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -116,3 +121,92 @@ func TestCSV(t *testing.T) {
 		})
 	})
 }
+
+type sampleImport struct {
+	Name    string        `csv:"name"`
+	Count   int           `csv:"count"`
+	Ratio   *float64      `csv:"ratio"`
+	Enabled bool          `csv:"enabled"`
+	Timeout time.Duration `csv:"timeout"`
+	Owner   SampleSubStruct
+	Ignored string
+}
+
+func TestGetCSV(t *testing.T) {
+	t.Run("RoundTrip", func(t *testing.T) {
+		rsp := httptest.NewRecorder()
+		WriteCSV(rsp, sampleGenerator())
+		require.Equal(t, http.StatusOK, rsp.Code)
+
+		out := []SampleStruct{}
+		require.NoError(t, GetCSV(io.NopCloser(rsp.Body), &out))
+		require.Len(t, out, 2)
+		for idx, sample := range sampleGenerator() {
+			assert.Equal(t, sample.FieldA, out[idx].FieldA)
+			assert.Equal(t, sample.FieldInt, out[idx].FieldInt)
+			assert.Equal(t, sample.FieldBool, out[idx].FieldBool)
+			assert.Equal(t, sample.Sub1.Sub1, out[idx].Sub1.Sub1)
+			assert.Equal(t, sample.Sub2.Sub1, out[idx].Sub2.Sub1)
+			assert.Empty(t, out[idx].NoCSV)
+		}
+	})
+	t.Run("Conversion", func(t *testing.T) {
+		in := "enabled,name,ratio,extra,timeout,count,this\ntrue,a,0.5,x,1m,3,owner\n,b,,,,,\n"
+		out := []*sampleImport{}
+		require.NoError(t, GetCSV(io.NopCloser(strings.NewReader(in)), &out))
+		require.Len(t, out, 2)
+		assert.Equal(t, "a", out[0].Name)
+		assert.Equal(t, 3, out[0].Count)
+		require.NotNil(t, out[0].Ratio)
+		assert.Equal(t, 0.5, *out[0].Ratio)
+		assert.True(t, out[0].Enabled)
+		assert.Equal(t, time.Minute, out[0].Timeout)
+		assert.Equal(t, "owner", out[0].Owner.Sub1)
+		assert.Equal(t, &sampleImport{Name: "b"}, out[1])
+	})
+	t.Run("Empty", func(t *testing.T) {
+		out := []sampleImport{{Name: "existing"}}
+		require.NoError(t, GetCSV(io.NopCloser(&bytes.Buffer{}), &out))
+		assert.Empty(t, out)
+
+		require.NoError(t, GetCSV(io.NopCloser(strings.NewReader("name,count\n")), &out))
+		assert.Empty(t, out)
+	})
+	t.Run("InvalidValues", func(t *testing.T) {
+		in := "name,count,enabled\na,1,true\nb,two,true\nc,3,maybe\n"
+		out := []sampleImport{}
+		err := GetCSV(io.NopCloser(strings.NewReader(in)), &out)
+		require.Error(t, err)
+		resp, ok := err.(ErrorResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, []FieldError{
+			{Field: "count", Row: 3, Message: "'two' is not a valid integer"},
+			{Field: "enabled", Row: 4, Message: "'maybe' is not a valid boolean"},
+		}, resp.Fields)
+		assert.Contains(t, resp.Message, "row 3, column 'count'")
+		assert.Empty(t, out)
+	})
+	t.Run("MalformedDocument", func(t *testing.T) {
+		out := []sampleImport{}
+		err := GetCSV(io.NopCloser(strings.NewReader("name,count\na\n")), &out)
+		require.Error(t, err)
+		assert.Equal(t, http.StatusBadRequest, err.(ErrorResponse).StatusCode)
+	})
+	t.Run("TooLarge", func(t *testing.T) {
+		in := "name\n" + strings.Repeat("a", maxRequestSize)
+		out := []sampleImport{}
+		err := GetCSV(io.NopCloser(strings.NewReader(in)), &out)
+		require.Error(t, err)
+		resp, ok := err.(ErrorResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, resp.StatusCode)
+		assert.Empty(t, out)
+	})
+	t.Run("InvalidDestination", func(t *testing.T) {
+		assert.Error(t, GetCSV(nil, &[]sampleImport{}))
+		assert.Error(t, GetCSV(io.NopCloser(strings.NewReader("name\n")), []sampleImport{}))
+		assert.Error(t, GetCSV(io.NopCloser(strings.NewReader("name\n")), &sampleImport{}))
+		assert.Error(t, GetCSV(io.NopCloser(strings.NewReader("name\n")), &[]string{}))
+	})
+}
@@ -1,6 +1,7 @@
 package gimlet
 
 import (
+	"encoding/xml"
 	"fmt"
 	"net/http"
 
@@ -14,9 +15,31 @@ import (
 // ErrorResponse also implements grip's message.Composer interface
 // which can simplify some error reporting on the client side.
 type ErrorResponse struct {
-	StatusCode   int    `bson:"status" json:"status" yaml:"status"`
-	Message      string `bson:"message" json:"message" yaml:"message"`
-	message.Base `bson:"metadata" json:"metadata" yaml:"metadata"`
+	StatusCode   int          `bson:"status" json:"status" yaml:"status" csv:"status" xml:"status"`
+	Message      string       `bson:"message" json:"message" yaml:"message" csv:"message" xml:"message"`
+	Fields       []FieldError `bson:"fields,omitempty" json:"fields,omitempty" yaml:"fields,omitempty" xml:"fields>field,omitempty"`
+	message.Base `bson:"metadata" json:"metadata" yaml:"metadata" xml:"-"`
+}
+
+// FieldError describes a problem with a single field of a request,
+// such as a parameter that failed validation. Row identifies the
+// line of tabular (e.g. CSV) documents.
+type FieldError struct {
+	Field   string `bson:"field" json:"field" yaml:"field" xml:"name"`
+	Source  string `bson:"source,omitempty" json:"source,omitempty" yaml:"source,omitempty" xml:"source,omitempty"`
+	Row     int    `bson:"row,omitempty" json:"row,omitempty" yaml:"row,omitempty" xml:"row,omitempty"`
+	Message string `bson:"message" json:"message" yaml:"message" xml:"message"`
+}
+
+func (e FieldError) Error() string {
+	switch {
+	case e.Row > 0:
+		return fmt.Sprintf("row %d, column '%s': %s", e.Row, e.Field, e.Message)
+	case e.Source == "":
+		return fmt.Sprintf("%s: %s", e.Field, e.Message)
+	default:
+		return fmt.Sprintf("%s '%s': %s", e.Source, e.Field, e.Message)
+	}
 }
 
 func (e ErrorResponse) Error() string {
@@ -26,3 +49,24 @@ func (e ErrorResponse) Error() string {
 func (e ErrorResponse) String() string   { return e.Error() }
 func (e ErrorResponse) Raw() interface{} { return e }
 func (e ErrorResponse) Loggable() bool   { return e.StatusCode > 399 }
+
+// MarshalXML omits the fields element from responses without field
+// errors, because encoding/xml always writes the parent element of
+// "fields>field" paths.
+func (e ErrorResponse) MarshalXML(enc *xml.Encoder, start xml.StartElement) error {
+	type fieldErrors struct {
+		Fields []FieldError `xml:"field"`
+	}
+
+	doc := struct {
+		StatusCode int          `xml:"status"`
+		Message    string       `xml:"message"`
+		Fields     *fieldErrors `xml:"fields,omitempty"`
+	}{StatusCode: e.StatusCode, Message: e.Message}
+
+	if len(e.Fields) > 0 {
+		doc.Fields = &fieldErrors{Fields: e.Fields}
+	}
+
+	return enc.EncodeElement(doc, start)
+}
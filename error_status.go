@@ -0,0 +1,102 @@
+package gimlet
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// errorStatus maps the errors that match a predicate to an error
+// response.
+type errorStatus struct {
+	match   func(error) bool
+	status  int
+	message string
+}
+
+var errorStatusRegistry = &struct {
+	sync.RWMutex
+	mappings []*errorStatus
+}{}
+
+// RegisterErrorStatus maps errors that match the target, as
+// determined by errors.Is, to the HTTP status and public message of
+// error responses. Use this to translate sentinel errors from
+// application code (e.g. "not found") into error responses, without
+// constructing an ErrorResponse in every handler.
+//
+// The registry applies to errors passed to newResponder based
+// constructors, such as MakeJSONErrorResponder, and to the errors
+// that RouteHandlers return from Parse, when the error is not already
+// an ErrorResponse. If the message is empty, the response uses the
+// text of the error. When multiple mappings match an error, the
+// mapping registered first takes precedence.
+//
+// The registry is process-wide: call the function that
+// RegisterErrorStatus returns to remove the mapping, for instance
+// when tests or applications that share a process register
+// conflicting mappings.
+func RegisterErrorStatus(target error, status int, message string) (func(), error) {
+	if target == nil {
+		return nil, errors.New("must specify an error to map")
+	}
+
+	return registerErrorStatus(func(err error) bool { return errors.Is(err, target) }, status, message)
+}
+
+// RegisterErrorType maps errors of the type T, as determined by
+// errors.As, to the HTTP status and public message of error
+// responses, as RegisterErrorStatus.
+func RegisterErrorType[T error](status int, message string) (func(), error) {
+	return registerErrorStatus(func(err error) bool {
+		var target T
+		return errors.As(err, &target)
+	}, status, message)
+}
+
+func registerErrorStatus(match func(error) bool, status int, message string) (func(), error) {
+	if status < http.StatusBadRequest || http.StatusText(status) == "" {
+		return nil, errors.Errorf("%d is not a valid error status", status)
+	}
+
+	mapping := &errorStatus{
+		match:   match,
+		status:  status,
+		message: message,
+	}
+
+	errorStatusRegistry.Lock()
+	defer errorStatusRegistry.Unlock()
+
+	errorStatusRegistry.mappings = append(errorStatusRegistry.mappings, mapping)
+
+	return func() { unregisterErrorStatus(mapping) }, nil
+}
+
+func unregisterErrorStatus(mapping *errorStatus) {
+	errorStatusRegistry.Lock()
+	defer errorStatusRegistry.Unlock()
+
+	for idx, m := range errorStatusRegistry.mappings {
+		if m == mapping {
+			errorStatusRegistry.mappings = append(errorStatusRegistry.mappings[:idx:idx], errorStatusRegistry.mappings[idx+1:]...)
+			return
+		}
+	}
+}
+
+// lookupErrorStatus returns the status and message of the first
+// registered mapping that matches the error.
+func lookupErrorStatus(err error) (int, string, bool) {
+	errorStatusRegistry.RLock()
+	defer errorStatusRegistry.RUnlock()
+
+	for _, mapping := range errorStatusRegistry.mappings {
+		if mapping.match(err) {
+			return mapping.status, mapping.message, true
+		}
+	}
+
+	return 0, "", false
+}
@@ -0,0 +1,90 @@
+package gimlet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	errStatusTestMissing      = errors.New("record is missing")
+	errStatusTestUnregistered = errors.New("record is private")
+)
+
+type statusTestConflict struct{ id string }
+
+func (e *statusTestConflict) Error() string { return fmt.Sprintf("record '%s' was modified", e.id) }
+
+func TestErrorStatusRegistry(t *testing.T) {
+	unregisterMissing, err := RegisterErrorStatus(errStatusTestMissing, http.StatusNotFound, "")
+	require.NoError(t, err)
+	defer unregisterMissing()
+	unregisterConflict, err := RegisterErrorType[*statusTestConflict](http.StatusConflict, "the record was modified")
+	require.NoError(t, err)
+	defer unregisterConflict()
+	unregisterShadowed, err := RegisterErrorStatus(errStatusTestMissing, http.StatusGone, "shadowed")
+	require.NoError(t, err)
+	defer unregisterShadowed()
+
+	t.Run("Validation", func(t *testing.T) {
+		_, err := RegisterErrorStatus(nil, http.StatusNotFound, "")
+		assert.Error(t, err)
+		_, err = RegisterErrorStatus(errStatusTestMissing, http.StatusOK, "")
+		assert.Error(t, err)
+		_, err = RegisterErrorType[*statusTestConflict](999, "")
+		assert.Error(t, err)
+	})
+	t.Run("Sentinel", func(t *testing.T) {
+		resp := MakeJSONInternalErrorResponder(errors.Wrap(errStatusTestMissing, "finding user"))
+		assert.Equal(t, http.StatusNotFound, resp.Status())
+		assert.Equal(t, "record is missing", resp.Data().(ErrorResponse).Message)
+
+		resp = MakeJSONErrorResponder(fmt.Errorf("finding user: %w", errStatusTestMissing))
+		assert.Equal(t, http.StatusNotFound, resp.Status())
+	})
+	t.Run("Type", func(t *testing.T) {
+		resp := MakeTextErrorResponder(fmt.Errorf("saving: %w", &statusTestConflict{id: "a"}))
+		assert.Equal(t, http.StatusConflict, resp.Status())
+		assert.Equal(t, "409 (Conflict): the record was modified", resp.Data())
+	})
+	t.Run("Unmapped", func(t *testing.T) {
+		resp := MakeJSONErrorResponder(errors.New("other"))
+		assert.Equal(t, http.StatusBadRequest, resp.Status())
+
+		resp = MakeJSONErrorResponder(ErrorResponse{StatusCode: http.StatusTeapot, Message: errStatusTestMissing.Error()})
+		assert.Equal(t, http.StatusTeapot, resp.Status())
+	})
+	t.Run("Handlers", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		handleHandler(&testHandler{parse: func(context.Context, *http.Request) error { return errStatusTestMissing }})(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+
+		handler := handleHandler(Typed(func(ctx context.Context, in struct{}) (struct{}, error) {
+			return struct{}{}, &statusTestConflict{id: "b"}
+		}))
+		rw = httptest.NewRecorder()
+		handler(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusConflict, rw.Code)
+		assert.Contains(t, rw.Body.String(), "the record was modified")
+	})
+	t.Run("Unregister", func(t *testing.T) {
+		unregister, err := RegisterErrorStatus(errStatusTestUnregistered, http.StatusForbidden, "")
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusForbidden, MakeJSONErrorResponder(errStatusTestUnregistered).Status())
+
+		unregister()
+		unregister()
+		assert.Equal(t, http.StatusBadRequest, MakeJSONErrorResponder(errStatusTestUnregistered).Status())
+
+		unregisterShadowed()
+		assert.Equal(t, http.StatusNotFound, MakeJSONErrorResponder(errStatusTestMissing).Status())
+		unregisterMissing()
+		assert.Equal(t, http.StatusBadRequest, MakeJSONErrorResponder(errStatusTestMissing).Status())
+	})
+}
@@ -1,9 +1,11 @@
 package gimlet
 
 import (
+	"encoding/xml"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestErrorResponse(t *testing.T) {
@@ -17,3 +19,24 @@ func TestErrorResponse(t *testing.T) {
 	assert.Contains(t, out, "coffee")
 	assert.Contains(t, out, "418")
 }
+
+func TestErrorResponseXML(t *testing.T) {
+	out, err := xml.Marshal(ErrorResponse{StatusCode: 400, Message: "bad"})
+	require.NoError(t, err)
+	assert.Equal(t, "<ErrorResponse><status>400</status><message>bad</message></ErrorResponse>", string(out))
+
+	in := ErrorResponse{
+		StatusCode: 400,
+		Message:    "bad",
+		Fields:     []FieldError{{Field: "a", Source: ParamSourceQuery, Message: "is required"}},
+	}
+	out, err = xml.Marshal(in)
+	require.NoError(t, err)
+	assert.Contains(t, string(out), "<fields><field><name>a</name>")
+
+	decoded := ErrorResponse{}
+	require.NoError(t, xml.Unmarshal(out, &decoded))
+	assert.Equal(t, in.StatusCode, decoded.StatusCode)
+	assert.Equal(t, in.Message, decoded.Message)
+	assert.Equal(t, in.Fields, decoded.Fields)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/url"
+	"strconv"
 )
 
 // RouteHandler provides an alternate method for defining routes with
@@ -41,7 +42,12 @@ func handleHandler(h RouteHandler) http.HandlerFunc {
 		defer cancel()
 
 		if err := handler.Parse(ctx, r); err != nil {
-			WriteResponse(w, newResponder(err, http.StatusBadRequest, JSON))
+			writeResponder(ctx, w, newResponder(err, http.StatusBadRequest, JSON))
+			return
+		}
+
+		if err := checkPreconditions(ctx, r, handler); err != nil {
+			writeResponder(ctx, w, newResponder(err, http.StatusInternalServerError, JSON))
 			return
 		}
 
@@ -51,21 +57,46 @@ func handleHandler(h RouteHandler) http.HandlerFunc {
 				StatusCode: http.StatusInternalServerError,
 				Message:    "undefined response",
 			}
-			WriteJSONResponse(w, e.StatusCode, e)
+			writeResponder(ctx, w, newResponder(e, e.StatusCode, JSON))
 			return
 		}
 
 		if err := resp.Validate(); err != nil {
-			WriteResponse(w, newResponder(err, http.StatusBadRequest, JSON))
+			writeResponder(ctx, w, newResponder(err, http.StatusBadRequest, JSON))
 			return
 		}
 
+		if n, ok := resp.(FormatNegotiator); ok {
+			w.Header().Add("Vary", "Accept")
+
+			format, err := negotiateFormat(r, resp.Format(), n.Formats())
+			if err != nil {
+				writeResponder(ctx, w, newResponder(err, http.StatusNotAcceptable, JSON))
+				return
+			}
+
+			if err := resp.SetFormat(format); err != nil {
+				writeResponder(ctx, w, newResponder(err, http.StatusInternalServerError, JSON))
+				return
+			}
+		}
+
 		// if this response is paginated, add the appropriate metadata.
-		if resp.Pages() != nil {
+		if pages := resp.Pages(); pages != nil {
 			routeURL := url.URL{Path: r.URL.Path, RawQuery: r.URL.RawQuery}
-			w.Header().Set("Link", resp.Pages().GetLinks(routeURL.String()))
+			if links := pages.GetLinks(routeURL.String()); links != "" {
+				w.Header().Set("Link", links)
+			}
+			if pages.TotalCount != nil {
+				w.Header().Set("X-Total-Count", strconv.Itoa(*pages.TotalCount))
+			}
+		}
+
+		if (r.Method == http.MethodGet || r.Method == http.MethodHead) && resp.Status() == http.StatusOK {
+			writeConditional(ctx, w, r, resp)
+			return
 		}
 
-		WriteResponse(w, resp)
+		writeResponder(ctx, w, resp)
 	}
 }
@@ -0,0 +1,406 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+)
+
+// ConditionalResponder is implemented by Responders that have
+// explicit validators, an entity tag (ETag) and a modification time,
+// for their representation. WriteResponse, and RouteHandlers, write
+// these validators in the ETag and Last-Modified headers, and
+// RouteHandlers respond to conditional GET and HEAD requests, with
+// the If-None-Match and If-Modified-Since headers, with a 304 (not
+// modified) response when the client's representation is current.
+//
+// The responders that gimlet constructs implement
+// ConditionalResponder, and users can access these methods with a
+// type assertion. When responders do not have an explicit entity tag,
+// RouteHandlers compute entity tags for the JSON and YAML responses to
+// GET and HEAD requests from the body of the response. Use
+// APIRoute.ETags to change this behavior.
+type ConditionalResponder interface {
+	Responder
+
+	// ETag returns the entity tag of the response, with its
+	// quotes and optional weak prefix (e.g. `W/"v1"`). SetETag
+	// quotes unquoted tags, and returns an error for invalid
+	// tags.
+	ETag() string
+	SetETag(string) error
+
+	// LastModified returns the modification time of the
+	// response, which is zero when unknown.
+	LastModified() time.Time
+	SetLastModified(time.Time)
+}
+
+// ConditionalHandler is an optional extension of the RouteHandler
+// interface for routes that modify resources. For requests with
+// unsafe methods (e.g. PUT, PATCH or DELETE) and If-Match,
+// If-None-Match or If-Unmodified-Since headers, RouteHandlers call
+// Validators after Parse and before Run, and respond with a 412
+// (precondition failed) without calling Run when the current state of
+// the resource does not satisfy the preconditions.
+//
+// Validators returns the entity tag and modification time of the
+// current representation of the resource, which are empty when the
+// resource does not exist.
+type ConditionalHandler interface {
+	RouteHandler
+	Validators(context.Context) (string, time.Time, error)
+}
+
+// ETagMode determines how RouteHandlers compute entity tags for
+// responses that do not have explicit entity tags.
+type ETagMode int
+
+const (
+	// ETagStrong computes strong entity tags from the body of
+	// JSON and YAML responses, and is the default.
+	ETagStrong ETagMode = iota
+	// ETagWeak computes weak entity tags, for responses that are
+	// semantically, but not byte-for-byte, equivalent, such as
+	// responses that intermediaries may compress.
+	ETagWeak
+	// ETagDisabled does not compute entity tags.
+	ETagDisabled
+)
+
+// ETags sets the mode that the route uses to compute entity tags for
+// its responses. By default, routes compute strong entity tags for
+// JSON and YAML responses to GET and HEAD requests.
+func (r *APIRoute) ETags(mode ETagMode) *APIRoute {
+	if mode < ETagStrong || mode > ETagDisabled {
+		grip.Warningf("%d is not a valid etag mode for route %s", mode, r.route)
+		return r
+	}
+
+	r.etagMode = mode
+	return r
+}
+
+func withETagMode(mode ETagMode, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), etagModeKey, mode)))
+	})
+}
+
+func getETagMode(ctx context.Context) ETagMode {
+	if mode, ok := ctx.Value(etagModeKey).(ETagMode); ok {
+		return mode
+	}
+
+	return ETagStrong
+}
+
+// ComputeETag returns an entity tag derived from the content of the
+// body, which is weak if specified.
+func ComputeETag(body []byte, weak bool) string {
+	sum := sha256.Sum256(body)
+	tag := `"` + base64.RawURLEncoding.EncodeToString(sum[:16]) + `"`
+	if weak {
+		return "W/" + tag
+	}
+
+	return tag
+}
+
+// formatETag validates an entity tag, quoting unquoted tags.
+func formatETag(tag string) (string, error) {
+	opaque := strings.TrimPrefix(tag, "W/")
+	if strings.HasPrefix(opaque, `"`) {
+		if len(opaque) < 2 || !strings.HasSuffix(opaque, `"`) {
+			return "", errors.Errorf("entity tag %s is not properly quoted", tag)
+		}
+		opaque = opaque[1 : len(opaque)-1]
+	} else if opaque == tag {
+		tag = `"` + tag + `"`
+	} else {
+		return "", errors.Errorf("entity tag %s is not properly quoted", tag)
+	}
+
+	for i := 0; i < len(opaque); i++ {
+		if c := opaque[i]; c <= ' ' || c == '"' || c == 0x7f {
+			return "", errors.Errorf("entity tag %s contains invalid characters", tag)
+		}
+	}
+
+	return tag, nil
+}
+
+func (r *responseHeaders) ETag() string { return r.etag }
+
+func (r *responseHeaders) SetETag(tag string) error {
+	if tag == "" {
+		r.etag = ""
+		return nil
+	}
+
+	tag, err := formatETag(tag)
+	if err != nil {
+		return err
+	}
+
+	r.etag = tag
+	return nil
+}
+
+func (r *responseHeaders) LastModified() time.Time     { return r.lastModified }
+func (r *responseHeaders) SetLastModified(t time.Time) { r.lastModified = t }
+
+// writeValidators sets the ETag and Last-Modified headers.
+func writeValidators(header http.Header, etag string, modified time.Time) {
+	if etag != "" {
+		header.Set("ETag", etag)
+	}
+
+	if !modified.IsZero() {
+		header.Set("Last-Modified", modified.UTC().Format(http.TimeFormat))
+	}
+}
+
+// parseETags returns the entity tags in the value of an If-Match or
+// If-None-Match header, which may be "*".
+func parseETags(value string) []string {
+	var tags []string
+
+	for {
+		value = strings.TrimLeft(value, " \t,")
+		if value == "" {
+			return tags
+		}
+
+		if value[0] == '*' {
+			tags = append(tags, "*")
+			value = value[1:]
+			continue
+		}
+
+		prefix := ""
+		if strings.HasPrefix(value, "W/") {
+			prefix, value = "W/", value[2:]
+		}
+
+		if !strings.HasPrefix(value, `"`) {
+			return tags
+		}
+
+		end := strings.IndexByte(value[1:], '"')
+		if end < 0 {
+			return tags
+		}
+
+		tags = append(tags, prefix+value[:end+2])
+		value = value[end+2:]
+	}
+}
+
+// matchETag reports whether the entity tag matches any of the tags
+// in the header value. Strong comparison requires both tags to be
+// strong, and weak comparison ignores the weak prefix.
+func matchETag(value, etag string, strong bool) bool {
+	if etag == "" {
+		return false
+	}
+
+	for _, tag := range parseETags(value) {
+		switch {
+		case tag == "*":
+			return true
+		case strong:
+			if tag == etag && !strings.HasPrefix(tag, "W/") {
+				return true
+			}
+		case strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/"):
+			return true
+		}
+	}
+
+	return false
+}
+
+// notModified reports whether the client's representation, as
+// described by the If-None-Match and If-Modified-Since headers of the
+// request, is current.
+func notModified(r *http.Request, etag string, modified time.Time) bool {
+	if inm := r.Header.Get("If-None-Match"); inm != "" {
+		return matchETag(inm, etag, false)
+	}
+
+	if ims := r.Header.Get("If-Modified-Since"); ims != "" && !modified.IsZero() {
+		since, err := http.ParseTime(ims)
+		return err == nil && !modified.Truncate(time.Second).After(since)
+	}
+
+	return false
+}
+
+// checkPreconditions evaluates the If-Match, If-Unmodified-Since and
+// If-None-Match headers of requests with unsafe methods, returning an
+// error response when the request does not satisfy them.
+func checkPreconditions(ctx context.Context, r *http.Request, handler RouteHandler) error {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return nil
+	}
+
+	ch, ok := handler.(ConditionalHandler)
+	if !ok {
+		return nil
+	}
+
+	im, ius, inm := r.Header.Get("If-Match"), r.Header.Get("If-Unmodified-Since"), r.Header.Get("If-None-Match")
+	if im == "" && ius == "" && inm == "" {
+		return nil
+	}
+
+	etag, modified, err := ch.Validators(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := ErrorResponse{StatusCode: http.StatusPreconditionFailed}
+	switch {
+	case im != "":
+		if !matchETag(im, etag, true) {
+			failed.Message = "the resource does not match the If-Match precondition"
+			return failed
+		}
+	case ius != "":
+		since, err := http.ParseTime(ius)
+		if err == nil && (modified.IsZero() || modified.Truncate(time.Second).After(since)) {
+			failed.Message = "the resource was modified after the If-Unmodified-Since precondition"
+			return failed
+		}
+	}
+
+	if inm != "" && matchETag(inm, etag, false) {
+		failed.Message = "the resource matches the If-None-Match precondition"
+		return failed
+	}
+
+	return nil
+}
+
+// writeConditional writes a response to a GET or HEAD request,
+// writing a 304 response without a body when the client's
+// representation is current.
+func writeConditional(ctx context.Context, w http.ResponseWriter, r *http.Request, resp Responder) {
+	var (
+		etag     string
+		modified time.Time
+	)
+	if cr, ok := resp.(ConditionalResponder); ok {
+		etag, modified = cr.ETag(), cr.LastModified()
+	}
+
+	if etag != "" || !modified.IsZero() {
+		if notModified(r, etag, modified) {
+			if hr, ok := resp.(HeaderResponder); ok {
+				writeHeaders(w, hr)
+			}
+			writeValidators(w.Header(), etag, modified)
+			writeNotModified(w, w.Header())
+			return
+		}
+
+		writeResponder(ctx, w, resp)
+		return
+	}
+
+	mode := getETagMode(ctx)
+	if _, ok := resp.(StreamResponder); ok || mode == ETagDisabled || (resp.Format() != JSON && resp.Format() != YAML) {
+		writeResponder(ctx, w, resp)
+		return
+	}
+
+	buf := &bufferedResponse{header: w.Header().Clone()}
+	writeResponder(ctx, buf, resp)
+
+	if buf.status == http.StatusOK {
+		etag = ComputeETag(buf.body.Bytes(), mode == ETagWeak)
+		buf.sent.Set("ETag", etag)
+
+		if notModified(r, etag, time.Time{}) {
+			writeNotModified(w, buf.sent)
+			return
+		}
+	}
+
+	buf.writeTo(w)
+}
+
+// writeNotModified writes a 304 response with the given headers,
+// omitting the headers that describe the body.
+func writeNotModified(w http.ResponseWriter, header http.Header) {
+	for key, values := range header {
+		w.Header()[key] = values
+	}
+
+	for _, key := range []string{"Content-Type", "Content-Length", "Trailer"} {
+		w.Header().Del(key)
+	}
+
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// bufferedResponse holds a response in memory, so that RouteHandlers
+// can compute the entity tag of the response before writing it.
+type bufferedResponse struct {
+	header http.Header
+	sent   http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	if b.status != 0 {
+		return
+	}
+
+	b.status = code
+	b.sent = b.header.Clone()
+	b.header = b.sent.Clone()
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.WriteHeader(http.StatusOK)
+	}
+
+	return b.body.Write(p)
+}
+
+// writeTo writes the buffered response, sending the headers set after
+// the status as trailers.
+func (b *bufferedResponse) writeTo(w http.ResponseWriter) {
+	if b.status == 0 {
+		b.WriteHeader(http.StatusOK)
+	}
+
+	for key, values := range b.sent {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(b.status)
+
+	if _, err := w.Write(b.body.Bytes()); err != nil {
+		grip.Warning(errors.Wrap(err, "problem writing buffered response"))
+	}
+
+	for key, values := range b.header {
+		if _, ok := b.sent[key]; !ok {
+			w.Header()[key] = values
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package gimlet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// conditionalTestHandler is a test handler with validators.
+type conditionalTestHandler struct {
+	testHandler
+	etag     string
+	modified time.Time
+}
+
+func (h *conditionalTestHandler) Factory() RouteHandler {
+	out := *h
+	return &out
+}
+
+func (h *conditionalTestHandler) Validators(ctx context.Context) (string, time.Time, error) {
+	return h.etag, h.modified, nil
+}
+
+func conditionalRequest(method string, headers map[string]string) *http.Request {
+	req := httptest.NewRequest(method, "/", nil)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	return req
+}
+
+func TestConditionalRequests(t *testing.T) {
+	modified := time.Date(2020, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("ComputedETag", func(t *testing.T) {
+		handler := handleHandler(respondWith(NewJSONResponse(map[string]int{"n": 1})))
+
+		rw := httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		etag := rw.Header().Get("ETag")
+		require.NotEmpty(t, etag)
+		assert.Equal(t, ComputeETag(rw.Body.Bytes(), false), etag)
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{"If-None-Match": `"other", ` + etag}))
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		assert.Empty(t, rw.Body.String())
+		assert.Equal(t, etag, rw.Header().Get("ETag"))
+		assert.Empty(t, rw.Header().Get("Content-Type"))
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{"If-None-Match": "W/" + etag}))
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{"If-None-Match": `"other"`}))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.NotEmpty(t, rw.Body.String())
+	})
+	t.Run("Formats", func(t *testing.T) {
+		for _, tc := range []struct {
+			name string
+			resp Responder
+			etag bool
+		}{
+			{name: "YAML", resp: NewYAMLResponse(map[string]int{"n": 1}), etag: true},
+			{name: "Text", resp: NewTextResponse("hello")},
+			{name: "Error", resp: MakeJSONErrorResponder(ErrorResponse{StatusCode: http.StatusNotFound})},
+			{name: "Stream", resp: NewNDJSONStreamResponse(streamTestItems(1, -1))},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				handleHandler(respondWith(tc.resp))(rw, conditionalRequest(http.MethodGet, nil))
+				assert.Equal(t, tc.etag, rw.Header().Get("ETag") != "")
+			})
+		}
+
+		rw := httptest.NewRecorder()
+		handleHandler(respondWith(NewJSONResponse("hello")))(rw, conditionalRequest(http.MethodPost, nil))
+		assert.Empty(t, rw.Header().Get("ETag"))
+	})
+	t.Run("Modes", func(t *testing.T) {
+		handler := handleHandler(respondWith(NewJSONResponse("hello")))
+
+		rw := httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, nil).WithContext(context.WithValue(context.Background(), etagModeKey, ETagWeak)))
+		assert.Equal(t, ComputeETag([]byte("\"hello\"\n"), true), rw.Header().Get("ETag"))
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, nil).WithContext(context.WithValue(context.Background(), etagModeKey, ETagDisabled)))
+		assert.Empty(t, rw.Header().Get("ETag"))
+
+		route := &APIRoute{}
+		assert.Equal(t, ETagWeak, route.ETags(ETagWeak).etagMode)
+		assert.Equal(t, ETagWeak, route.ETags(ETagMode(42)).etagMode)
+	})
+	t.Run("ExplicitValidators", func(t *testing.T) {
+		resp := func(context.Context) Responder {
+			resp := Negotiate(NewJSONResponse("hello"), JSON, YAML)
+			require.NoError(t, resp.(ConditionalResponder).SetETag("v1"))
+			resp.(ConditionalResponder).SetLastModified(modified)
+			resp.(HeaderResponder).Header().Set("Cache-Control", "max-age=60")
+			return resp
+		}
+		handler := handleHandler(&conditionalTestHandler{testHandler: testHandler{run: resp}})
+
+		rw := httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, `"v1"`, rw.Header().Get("ETag"))
+		assert.Equal(t, "Fri, 01 May 2020 12:00:00 GMT", rw.Header().Get("Last-Modified"))
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodHead, map[string]string{"If-None-Match": `"v1"`}))
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+		assert.Equal(t, "max-age=60", rw.Header().Get("Cache-Control"))
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{"If-Modified-Since": modified.Add(time.Minute).Format(http.TimeFormat)}))
+		assert.Equal(t, http.StatusNotModified, rw.Code)
+
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{"If-Modified-Since": modified.Add(-time.Minute).Format(http.TimeFormat)}))
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		// If-None-Match takes precedence over If-Modified-Since.
+		rw = httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodGet, map[string]string{
+			"If-None-Match":     `"v2"`,
+			"If-Modified-Since": modified.Add(time.Minute).Format(http.TimeFormat),
+		}))
+		assert.Equal(t, http.StatusOK, rw.Code)
+
+		rw = httptest.NewRecorder()
+		WriteResponse(rw, resp(context.Background()))
+		assert.Equal(t, `"v1"`, rw.Header().Get("ETag"))
+	})
+	t.Run("Preconditions", func(t *testing.T) {
+		var runs int
+		h := &conditionalTestHandler{etag: `"v1"`, modified: modified, testHandler: testHandler{run: func(context.Context) Responder {
+			runs++
+			return NewJSONResponse("updated")
+		}}}
+		handler := handleHandler(h)
+
+		for _, tc := range []struct {
+			name    string
+			method  string
+			headers map[string]string
+			status  int
+		}{
+			{name: "IfMatch", method: http.MethodPut, headers: map[string]string{"If-Match": `"v1"`}, status: http.StatusOK},
+			{name: "IfMatchAny", method: http.MethodDelete, headers: map[string]string{"If-Match": "*"}, status: http.StatusOK},
+			{name: "IfMatchMismatch", method: http.MethodPut, headers: map[string]string{"If-Match": `"v0"`}, status: http.StatusPreconditionFailed},
+			{name: "IfMatchWeak", method: http.MethodPatch, headers: map[string]string{"If-Match": `W/"v1"`}, status: http.StatusPreconditionFailed},
+			{name: "IfUnmodifiedSince", method: http.MethodPut, headers: map[string]string{"If-Unmodified-Since": modified.Format(http.TimeFormat)}, status: http.StatusOK},
+			{name: "IfUnmodifiedSinceStale", method: http.MethodPut, headers: map[string]string{"If-Unmodified-Since": modified.Add(-time.Hour).Format(http.TimeFormat)}, status: http.StatusPreconditionFailed},
+			{name: "IfNoneMatchAny", method: http.MethodPut, headers: map[string]string{"If-None-Match": "*"}, status: http.StatusPreconditionFailed},
+			{name: "SafeMethod", method: http.MethodGet, headers: map[string]string{"If-Match": `"v0"`}, status: http.StatusOK},
+			{name: "NoHeaders", method: http.MethodPost, status: http.StatusOK},
+		} {
+			t.Run(tc.name, func(t *testing.T) {
+				runs = 0
+				rw := httptest.NewRecorder()
+				handler(rw, conditionalRequest(tc.method, tc.headers))
+				assert.Equal(t, tc.status, rw.Code)
+				assert.Equal(t, tc.status == http.StatusOK, runs == 1)
+			})
+		}
+
+		h.etag = ""
+		rw := httptest.NewRecorder()
+		handler(rw, conditionalRequest(http.MethodPut, map[string]string{"If-None-Match": "*"}))
+		assert.Equal(t, http.StatusOK, rw.Code)
+	})
+	t.Run("ETags", func(t *testing.T) {
+		for in, out := range map[string]string{"v1": `"v1"`, `"v1"`: `"v1"`, `W/"v1"`: `W/"v1"`} {
+			tag, err := formatETag(in)
+			require.NoError(t, err)
+			assert.Equal(t, out, tag)
+		}
+		for _, in := range []string{`"v1`, `W/v1`, "v 1", `"v"1"`} {
+			_, err := formatETag(in)
+			assert.Error(t, err, in)
+		}
+
+		assert.Equal(t, []string{`"a"`, `W/"b,c"`, "*"}, parseETags(` "a",W/"b,c" , *`))
+		assert.True(t, matchETag(`W/"a"`, `"a"`, false))
+		assert.False(t, matchETag(`W/"a"`, `"a"`, true))
+		assert.False(t, matchETag("*", "", false))
+	})
+}
@@ -0,0 +1,320 @@
+package gimlet
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// FormatNegotiator is an optional extension of the Responder
+// interface. When a RouteHandler returns a Responder that implements
+// FormatNegotiator, gimlet chooses the output format from the formats
+// that the responder supports, based on the request's "format" query
+// parameter (e.g. "?format=yaml") or, failing that, its Accept
+// header. Requests that do not accept any of the supported formats
+// receive a 406 response.
+//
+// When the request does not express a preference, the responder's
+// own format is used if it is supported, and otherwise the first
+// supported format.
+type FormatNegotiator interface {
+	Formats() []OutputFormat
+}
+
+// Negotiate wraps a Responder so that gimlet chooses its output
+// format from the given formats. When the client accepts several
+// formats equally, gimlet prefers the responder's own format, and
+// then the formats in the order given.
+func Negotiate(resp Responder, formats ...OutputFormat) Responder {
+	return &negotiatedResponder{Responder: resp, formats: formats}
+}
+
+// negotiatedResponder forwards the HeaderResponder and
+// ConditionalResponder methods to the responder that it wraps, when
+// the responder implements these interfaces.
+type negotiatedResponder struct {
+	Responder
+	formats  []OutputFormat
+	fallback responseHeaders
+}
+
+func (r *negotiatedResponder) Formats() []OutputFormat { return r.formats }
+
+func (r *negotiatedResponder) Header() http.Header {
+	if hr, ok := r.Responder.(HeaderResponder); ok {
+		return hr.Header()
+	}
+	return r.fallback.Header()
+}
+
+func (r *negotiatedResponder) Trailer() http.Header {
+	if hr, ok := r.Responder.(HeaderResponder); ok {
+		return hr.Trailer()
+	}
+	return r.fallback.Trailer()
+}
+
+func (r *negotiatedResponder) Cookies() []*http.Cookie {
+	if hr, ok := r.Responder.(HeaderResponder); ok {
+		return hr.Cookies()
+	}
+	return r.fallback.Cookies()
+}
+
+func (r *negotiatedResponder) AddCookie(c *http.Cookie) error {
+	if hr, ok := r.Responder.(HeaderResponder); ok {
+		return hr.AddCookie(c)
+	}
+	return r.fallback.AddCookie(c)
+}
+
+func (r *negotiatedResponder) ETag() string {
+	if cr, ok := r.Responder.(ConditionalResponder); ok {
+		return cr.ETag()
+	}
+	return r.fallback.ETag()
+}
+
+func (r *negotiatedResponder) SetETag(tag string) error {
+	if cr, ok := r.Responder.(ConditionalResponder); ok {
+		return cr.SetETag(tag)
+	}
+	return r.fallback.SetETag(tag)
+}
+
+func (r *negotiatedResponder) LastModified() time.Time {
+	if cr, ok := r.Responder.(ConditionalResponder); ok {
+		return cr.LastModified()
+	}
+	return r.fallback.LastModified()
+}
+
+func (r *negotiatedResponder) SetLastModified(t time.Time) {
+	if cr, ok := r.Responder.(ConditionalResponder); ok {
+		cr.SetLastModified(t)
+		return
+	}
+	r.fallback.SetLastModified(t)
+}
+
+// ParseOutputFormat returns the output format with the given name, as
+// returned by OutputFormat.String, or a common alias (e.g. "yml").
+func ParseOutputFormat(name string) (OutputFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "json":
+		return JSON, nil
+	case "text", "txt", "plain":
+		return TEXT, nil
+	case "html":
+		return HTML, nil
+	case "yaml", "yml":
+		return YAML, nil
+	case "binary", "bin":
+		return BINARY, nil
+	case "csv":
+		return CSV, nil
+	case "ndjson", "jsonl":
+		return NDJSON, nil
+	case "sse", "event-stream":
+		return SSE, nil
+	case "bson":
+		return BSON, nil
+	case "msgpack", "mpk":
+		return MSGPACK, nil
+	case "xml":
+		return XML, nil
+	default:
+		return -1, fmt.Errorf("'%s' is not a supported output format", name)
+	}
+}
+
+// mediaTypes returns the media types that clients may use to request
+// the format.
+func (o OutputFormat) mediaTypes() []string {
+	switch o {
+	case JSON:
+		return []string{"application/json"}
+	case TEXT:
+		return []string{"text/plain"}
+	case HTML:
+		return []string{"text/html", "application/html"}
+	case YAML:
+		return []string{"application/yaml", "application/x-yaml", "text/yaml"}
+	case BINARY:
+		return []string{"application/octet-stream"}
+	case CSV:
+		return []string{"text/csv", "application/csv"}
+	case NDJSON:
+		return []string{"application/x-ndjson", "application/ndjson", "application/jsonl"}
+	case SSE:
+		return []string{"text/event-stream"}
+	case BSON:
+		return []string{"application/bson"}
+	case MSGPACK:
+		return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+	case XML:
+		return []string{"application/xml", "text/xml"}
+	default:
+		return nil
+	}
+}
+
+// mediaTypeSuffix returns the structured syntax suffix (RFC 6839) for
+// the format, so that vendor media types like
+// "application/vnd.example.v2+json" select a format.
+func (o OutputFormat) mediaTypeSuffix() string {
+	switch o {
+	case JSON:
+		return "+json"
+	case YAML:
+		return "+yaml"
+	case XML:
+		return "+xml"
+	default:
+		return ""
+	}
+}
+
+type acceptRange struct {
+	mediaType string
+	quality   float64
+}
+
+func parseAccept(r *http.Request) []acceptRange {
+	var out []acceptRange
+
+	for _, accept := range r.Header.Values("Accept") {
+		for _, value := range strings.Split(accept, ",") {
+			mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(value))
+			if err != nil {
+				continue
+			}
+
+			q := 1.0
+			if qv, ok := params["q"]; ok {
+				if q, err = strconv.ParseFloat(qv, 64); err != nil || q < 0 || q > 1 {
+					continue
+				}
+			}
+
+			out = append(out, acceptRange{mediaType: mediaType, quality: q})
+		}
+	}
+
+	return out
+}
+
+// quality returns the quality that the accepted ranges assign to the
+// format, using the most specific matching range, or -1 if no range
+// matches the format.
+func (o OutputFormat) quality(ranges []acceptRange) float64 {
+	var (
+		quality     = -1.0
+		specificity = -1
+	)
+
+	for _, ar := range ranges {
+		level := -1
+		switch {
+		case ar.mediaType == "*/*":
+			level = 0
+		case strings.HasSuffix(ar.mediaType, "/*"):
+			prefix := strings.TrimSuffix(ar.mediaType, "*")
+			for _, mt := range o.mediaTypes() {
+				if strings.HasPrefix(mt, prefix) {
+					level = 1
+				}
+			}
+		default:
+			for _, mt := range o.mediaTypes() {
+				if mt == ar.mediaType {
+					level = 2
+				}
+			}
+			if suffix := o.mediaTypeSuffix(); level < 0 && suffix != "" && strings.HasSuffix(ar.mediaType, suffix) {
+				level = 2
+			}
+		}
+
+		if level > specificity || (level == specificity && level >= 0 && ar.quality > quality) {
+			specificity, quality = level, ar.quality
+		}
+	}
+
+	return quality
+}
+
+// negotiateFormat selects the output format for the request from the
+// supported formats, returning an error if the client does not accept
+// any of them.
+func negotiateFormat(r *http.Request, current OutputFormat, supported []OutputFormat) (OutputFormat, error) {
+	if len(supported) == 0 {
+		return current, nil
+	}
+
+	if name := r.URL.Query().Get("format"); name != "" {
+		format, err := ParseOutputFormat(name)
+		if err == nil && outputFormatSliceContains(supported, format) {
+			return format, nil
+		}
+
+		return -1, notAcceptable(supported)
+	}
+
+	ranges := parseAccept(r)
+	if len(ranges) == 0 {
+		if outputFormatSliceContains(supported, current) {
+			return current, nil
+		}
+		return supported[0], nil
+	}
+
+	var (
+		best    OutputFormat = -1
+		quality              = 0.0
+	)
+
+	// prefer the responder's own format, and then the order of the
+	// supported formats, when the client accepts several formats
+	// equally.
+	candidates := supported
+	if outputFormatSliceContains(supported, current) {
+		candidates = append([]OutputFormat{current}, supported...)
+	}
+
+	for _, format := range candidates {
+		if q := format.quality(ranges); q > quality {
+			best, quality = format, q
+		}
+	}
+
+	if best < 0 {
+		return -1, notAcceptable(supported)
+	}
+
+	return best, nil
+}
+
+func notAcceptable(supported []OutputFormat) error {
+	names := make([]string, 0, len(supported))
+	for _, f := range supported {
+		names = append(names, f.String())
+	}
+
+	return ErrorResponse{
+		StatusCode: http.StatusNotAcceptable,
+		Message:    fmt.Sprintf("no acceptable output format, supported formats are [%s]", strings.Join(names, ", ")),
+	}
+}
+
+func outputFormatSliceContains(formats []OutputFormat, format OutputFormat) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,93 @@
+package gimlet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		url       string
+		accept    []string
+		current   OutputFormat
+		supported []OutputFormat
+		expected  OutputFormat
+		err       bool
+	}{
+		{name: "NoPreference", url: "/", current: JSON, supported: []OutputFormat{YAML, JSON}, expected: JSON},
+		{name: "NoPreferenceUnsupportedCurrent", url: "/", current: TEXT, supported: []OutputFormat{YAML, JSON}, expected: YAML},
+		{name: "NoSupportedFormats", url: "/", accept: []string{"text/csv"}, current: JSON, expected: JSON},
+		{name: "Exact", url: "/", accept: []string{"application/yaml"}, current: JSON, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "Alias", url: "/", accept: []string{"text/csv"}, current: JSON, supported: []OutputFormat{JSON, CSV}, expected: CSV},
+		{name: "Quality", url: "/", accept: []string{"application/json;q=0.5, application/yaml;q=0.8"}, current: JSON, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "MultipleHeaders", url: "/", accept: []string{"application/json;q=0.1", "text/csv"}, current: JSON, supported: []OutputFormat{JSON, CSV}, expected: CSV},
+		{name: "Wildcard", url: "/", accept: []string{"*/*"}, current: YAML, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "TypeWildcard", url: "/", accept: []string{"text/*"}, current: JSON, supported: []OutputFormat{JSON, CSV, TEXT}, expected: CSV},
+		{name: "SpecificOverridesWildcard", url: "/", accept: []string{"*/*, application/json;q=0"}, current: JSON, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "Suffix", url: "/", accept: []string{"application/vnd.example.v2+yaml"}, current: JSON, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "QueryOverridesAccept", url: "/?format=yml", accept: []string{"application/json"}, current: JSON, supported: []OutputFormat{JSON, YAML}, expected: YAML},
+		{name: "UnsupportedQuery", url: "/?format=csv", current: JSON, supported: []OutputFormat{JSON, YAML}, err: true},
+		{name: "UnknownQuery", url: "/?format=pdf", current: JSON, supported: []OutputFormat{JSON, YAML}, err: true},
+		{name: "NotAcceptable", url: "/", accept: []string{"application/xml"}, current: JSON, supported: []OutputFormat{JSON, YAML}, err: true},
+		{name: "ZeroQuality", url: "/", accept: []string{"application/json;q=0"}, current: JSON, supported: []OutputFormat{JSON}, err: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.url, nil)
+			for _, accept := range tc.accept {
+				req.Header.Add("Accept", accept)
+			}
+
+			format, err := negotiateFormat(req, tc.current, tc.supported)
+			if tc.err {
+				require.Error(t, err)
+				assert.Equal(t, http.StatusNotAcceptable, err.(ErrorResponse).StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, format)
+		})
+	}
+}
+
+func TestNegotiatedHandler(t *testing.T) {
+	handler := handleHandler(&testHandler{run: func(context.Context) Responder {
+		return Negotiate(NewJSONResponse(map[string]string{"name": "gimlet"}), JSON, YAML)
+	}})
+
+	rw := httptest.NewRecorder()
+	handler(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, JSON.ContentType(), rw.Header().Get("Content-Type"))
+	assert.Equal(t, "Accept", rw.Header().Get("Vary"))
+
+	rw = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/yaml")
+	handler(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, YAML.ContentType(), rw.Header().Get("Content-Type"))
+	assert.Equal(t, "name: gimlet", strings.TrimSpace(rw.Body.String()))
+
+	rw = httptest.NewRecorder()
+	handler(rw, httptest.NewRequest(http.MethodGet, "/?format=csv", nil))
+	assert.Equal(t, http.StatusNotAcceptable, rw.Code)
+	assert.Contains(t, rw.Body.String(), "[json, yaml]")
+}
+
+func TestParseOutputFormat(t *testing.T) {
+	for _, f := range []OutputFormat{JSON, TEXT, HTML, YAML, BINARY, CSV, NDJSON, SSE, BSON, MSGPACK, XML} {
+		out, err := ParseOutputFormat(f.String())
+		require.NoError(t, err)
+		assert.Equal(t, f, out)
+	}
+
+	_, err := ParseOutputFormat("pdf")
+	assert.Error(t, err)
+}
@@ -0,0 +1,404 @@
+package gimlet
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/gimlet/util"
+	"github.com/tychoish/grip"
+	"github.com/tychoish/grip/message"
+)
+
+// OperationStatus describes the state of an asynchronous operation.
+type OperationStatus string
+
+const (
+	OperationPending   OperationStatus = "pending"
+	OperationRunning   OperationStatus = "running"
+	OperationSucceeded OperationStatus = "succeeded"
+	OperationFailed    OperationStatus = "failed"
+	OperationCanceled  OperationStatus = "canceled"
+)
+
+// IsFinished reports whether the operation has stopped running.
+func (s OperationStatus) IsFinished() bool {
+	switch s {
+	case OperationSucceeded, OperationFailed, OperationCanceled:
+		return true
+	default:
+		return false
+	}
+}
+
+// Operation is the state of an asynchronous operation, as reported by
+// the operation's status route.
+type Operation struct {
+	ID          string          `bson:"_id" json:"id" yaml:"id"`
+	Status      OperationStatus `bson:"status" json:"status" yaml:"status"`
+	Progress    float64         `bson:"progress" json:"progress" yaml:"progress"`
+	Result      interface{}     `bson:"result,omitempty" json:"result,omitempty" yaml:"result,omitempty"`
+	Error       *ErrorResponse  `bson:"error,omitempty" json:"error,omitempty" yaml:"error,omitempty"`
+	CreatedAt   time.Time       `bson:"created_at" json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time       `bson:"updated_at" json:"updated_at" yaml:"updated_at"`
+	CompletedAt time.Time       `bson:"completed_at,omitempty" json:"completed_at,omitempty" yaml:"completed_at,omitempty"`
+	ExpiresAt   time.Time       `bson:"expires_at,omitempty" json:"expires_at,omitempty" yaml:"expires_at,omitempty"`
+}
+
+func (op Operation) isExpired(now time.Time) bool {
+	return !op.ExpiresAt.IsZero() && !now.Before(op.ExpiresAt)
+}
+
+// OperationStore persists the state of asynchronous operations.
+// Implementations must not return operations after their expiration
+// time, and may delete expired operations.
+type OperationStore interface {
+	// Put creates or replaces the operation.
+	Put(context.Context, Operation) error
+	// Get returns the operation with the ID, and false if there
+	// is no such operation.
+	Get(context.Context, string) (Operation, bool, error)
+	// Delete removes the operation, and is not an error if there
+	// is no such operation.
+	Delete(context.Context, string) error
+}
+
+const operationStoreCleanInterval = time.Minute
+
+// NewInMemoryOperationStore returns an OperationStore which keeps
+// operations in memory, and periodically removes expired operations
+// until the context is canceled.
+func NewInMemoryOperationStore(ctx context.Context) OperationStore {
+	s := &operationStore{ops: map[string]Operation{}}
+
+	go func() {
+		timer := time.NewTimer(operationStoreCleanInterval)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				s.clean()
+				timer.Reset(operationStoreCleanInterval)
+			}
+		}
+	}()
+
+	return s
+}
+
+type operationStore struct {
+	mu  sync.RWMutex
+	ops map[string]Operation
+}
+
+func (s *operationStore) clean() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for id, op := range s.ops {
+		if op.isExpired(now) {
+			delete(s.ops, id)
+		}
+	}
+}
+
+func (s *operationStore) Put(_ context.Context, op Operation) error {
+	if op.ID == "" {
+		return errors.New("operation must have an id")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ops[op.ID] = op
+	return nil
+}
+
+func (s *operationStore) Get(_ context.Context, id string) (Operation, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	op, ok := s.ops[id]
+	if !ok || op.isExpired(time.Now()) {
+		return Operation{}, false, nil
+	}
+
+	return op, true, nil
+}
+
+func (s *operationStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.ops, id)
+	return nil
+}
+
+// OperationFunc is the body of an asynchronous operation. The
+// function reports its progress, between 0 and 1, with the progress
+// function, and should return when the context is canceled. The
+// result of the function, or its error, is the outcome of the
+// operation.
+type OperationFunc func(ctx context.Context, progress func(float64)) (interface{}, error)
+
+// OperationOptions configures an OperationManager.
+type OperationOptions struct {
+	// Store persists the operations, and defaults to an in-memory
+	// store.
+	Store OperationStore
+	// TTL is the time that operations remain available after
+	// they finish, and defaults to an hour.
+	TTL time.Duration
+	// RouteName is the name of the status route, and defaults to
+	// "operation".
+	RouteName string
+}
+
+// OperationManager runs asynchronous operations for RouteHandlers
+// whose work takes too long to complete within a request. Handlers
+// start operations with Start, which responds with 202 (accepted)
+// and the location of the operation's status route. Clients poll the
+// status route for the progress and outcome of the operation, and
+// cancel operations, or remove finished operations, with DELETE
+// requests to the status route.
+type OperationManager struct {
+	ctx     context.Context
+	opts    OperationOptions
+	path    string
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewOperationManager constructs an OperationManager. Canceling the
+// context cancels all running operations.
+func NewOperationManager(ctx context.Context, opts OperationOptions) *OperationManager {
+	if opts.Store == nil {
+		opts.Store = NewInMemoryOperationStore(ctx)
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = time.Hour
+	}
+	if opts.RouteName == "" {
+		opts.RouteName = "operation"
+	}
+
+	return &OperationManager{
+		ctx:     ctx,
+		opts:    opts,
+		cancels: map[string]context.CancelFunc{},
+	}
+}
+
+// AddRoutes registers the status route for operations, at
+// "<path>/{id}", with the application, and returns the route so that
+// callers can configure it further (e.g. with authentication
+// middleware.) The route responds to GET requests with the operation
+// and to DELETE requests by canceling or removing the operation.
+func (m *OperationManager) AddRoutes(app *APIApp, path string) *APIRoute {
+	m.path = strings.TrimSuffix(path, "/")
+
+	return app.AddRoute(m.path + "/{id}").Name(m.opts.RouteName).Get().Delete().RouteHandler(&operationHandler{manager: m})
+}
+
+// Get returns the operation with the ID, and false if there is no
+// such operation or the operation has expired.
+func (m *OperationManager) Get(ctx context.Context, id string) (Operation, bool, error) {
+	return m.opts.Store.Get(ctx, id)
+}
+
+// Start runs the function in the background, and returns a Responder
+// with a 202 (accepted) status, the operation as its body, and the
+// location of the operation's status route in the Location
+// header. The operation's context has the values of the request's
+// context, but is not canceled when the request finishes.
+func (m *OperationManager) Start(ctx context.Context, fn OperationFunc) Responder {
+	id, err := util.RandomString()
+	if err != nil {
+		return MakeJSONInternalErrorResponder(errors.Wrap(err, "problem generating operation id"))
+	}
+
+	now := time.Now()
+	op := Operation{ID: id, Status: OperationPending, CreatedAt: now, UpdatedAt: now}
+	if err := m.opts.Store.Put(ctx, op); err != nil {
+		return MakeJSONInternalErrorResponder(errors.Wrap(err, "problem saving operation"))
+	}
+
+	opctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(m.ctx, cancel)
+
+	m.mu.Lock()
+	m.cancels[id] = cancel
+	m.mu.Unlock()
+
+	go func() {
+		defer stop()
+		defer cancel()
+		m.run(opctx, id, fn)
+	}()
+
+	resp := &responderImpl{data: op, format: JSON, status: http.StatusAccepted}
+	if location := m.location(ctx, id); location != "" {
+		resp.Header().Set("Location", location)
+	}
+
+	return resp
+}
+
+func (m *OperationManager) location(ctx context.Context, id string) string {
+	if location, err := GetURL(ctx, m.opts.RouteName, "id", id); err == nil {
+		return location
+	}
+
+	if m.path == "" {
+		grip.Warningf("cannot determine the location of operation '%s' without a status route", id)
+		return ""
+	}
+
+	return fmt.Sprintf("%s/%s", m.path, id)
+}
+
+func (m *OperationManager) run(ctx context.Context, id string, fn OperationFunc) {
+	m.update(ctx, id, func(op *Operation) { op.Status = OperationRunning })
+
+	var (
+		result interface{}
+		err    error
+	)
+	func() {
+		defer func() {
+			if p := recover(); p != nil {
+				err = errors.Errorf("operation panicked: %v", p)
+			}
+		}()
+
+		result, err = fn(ctx, func(progress float64) {
+			m.update(ctx, id, func(op *Operation) {
+				op.Progress = min(max(progress, 0), 1)
+			})
+		})
+	}()
+
+	m.mu.Lock()
+	delete(m.cancels, id)
+	m.mu.Unlock()
+
+	m.update(ctx, id, func(op *Operation) {
+		switch {
+		case ctx.Err() != nil:
+			op.Status = OperationCanceled
+		case err != nil:
+			eresp, _ := newResponder(err, http.StatusInternalServerError, JSON).Data().(ErrorResponse)
+			op.Status = OperationFailed
+			op.Error = &eresp
+		default:
+			op.Status = OperationSucceeded
+			op.Progress = 1
+			op.Result = result
+		}
+	})
+
+	if err != nil && ctx.Err() == nil {
+		GetLogger(ctx).Warning(message.WrapError(err, message.Fields{
+			"message":   "asynchronous operation failed",
+			"operation": id,
+		}))
+	}
+}
+
+// update modifies the stored operation, finishing operations that
+// reach a final status. Finished operations do not change.
+func (m *OperationManager) update(ctx context.Context, id string, fn func(*Operation)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// the operation context may be canceled, but the state of the
+	// operation must still be stored.
+	ctx = context.WithoutCancel(ctx)
+
+	op, ok, err := m.opts.Store.Get(ctx, id)
+	if err != nil {
+		grip.Warning(errors.Wrapf(err, "problem finding operation '%s'", id))
+		return
+	}
+
+	if !ok || op.Status.IsFinished() {
+		return
+	}
+
+	fn(&op)
+	op.UpdatedAt = time.Now()
+	if op.Status.IsFinished() {
+		op.CompletedAt = op.UpdatedAt
+		op.ExpiresAt = op.CompletedAt.Add(m.opts.TTL)
+	}
+
+	if err := m.opts.Store.Put(ctx, op); err != nil {
+		grip.Warning(errors.Wrapf(err, "problem saving operation '%s'", id))
+	}
+}
+
+// Cancel cancels an operation that has not finished, returning the
+// state of the operation, and false if the operation does not exist.
+func (m *OperationManager) Cancel(ctx context.Context, id string) (Operation, bool, error) {
+	m.mu.Lock()
+	if cancel, ok := m.cancels[id]; ok {
+		cancel()
+	}
+	m.mu.Unlock()
+
+	// operations that run elsewhere cannot be interrupted, but
+	// their results are discarded.
+	m.update(ctx, id, func(op *Operation) { op.Status = OperationCanceled })
+
+	return m.opts.Store.Get(ctx, id)
+}
+
+// operationHandler implements the status route of operations.
+type operationHandler struct {
+	manager *OperationManager
+	id      string
+	method  string
+}
+
+func (h *operationHandler) Factory() RouteHandler {
+	return &operationHandler{manager: h.manager}
+}
+
+func (h *operationHandler) Parse(ctx context.Context, r *http.Request) error {
+	h.id = GetParam(r, "id")
+	h.method = r.Method
+
+	return nil
+}
+
+func (h *operationHandler) Run(ctx context.Context) Responder {
+	op, ok, err := h.manager.Get(ctx, h.id)
+	if err == nil && ok && h.method == http.MethodDelete {
+		if op.Status.IsFinished() {
+			if err = h.manager.opts.Store.Delete(ctx, h.id); err == nil {
+				return &responderImpl{data: "", format: TEXT, status: http.StatusNoContent}
+			}
+		} else {
+			op, ok, err = h.manager.Cancel(ctx, h.id)
+		}
+	}
+
+	switch {
+	case err != nil:
+		return MakeJSONInternalErrorResponder(errors.Wrapf(err, "problem accessing operation '%s'", h.id))
+	case !ok:
+		return MakeJSONErrorResponder(ErrorResponse{
+			StatusCode: http.StatusNotFound,
+			Message:    fmt.Sprintf("operation '%s' does not exist", h.id),
+		})
+	default:
+		return NewJSONResponse(op)
+	}
+}
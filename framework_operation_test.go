@@ -0,0 +1,159 @@
+package gimlet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOperations(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	manager := NewOperationManager(ctx, OperationOptions{TTL: time.Minute})
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	app := NewApp()
+	app.SetPrefix("api")
+	report := func(ctx context.Context, progress func(float64)) (interface{}, error) {
+		progress(0.5)
+		started <- struct{}{}
+		select {
+		case <-release:
+			return map[string]int{"rows": 10}, nil
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	app.AddRoute("/reports").Version(1).Post().RouteHandler(&testHandler{run: func(ctx context.Context) Responder {
+		return manager.Start(ctx, report)
+	}})
+	manager.AddRoutes(app, "/operations/").Version(1)
+
+	handler, err := app.Handler()
+	require.NoError(t, err)
+
+	do := func(method, path string) (*httptest.ResponseRecorder, Operation) {
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest(method, path, nil))
+		op := Operation{}
+		if rw.Code == http.StatusOK || rw.Code == http.StatusAccepted {
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &op))
+		}
+		return rw, op
+	}
+
+	poll := func(location string, status OperationStatus) Operation {
+		var op Operation
+		require.Eventually(t, func() bool {
+			_, op = do(http.MethodGet, location)
+			return op.Status == status
+		}, time.Second, 5*time.Millisecond)
+		return op
+	}
+
+	t.Run("Success", func(t *testing.T) {
+		rw, op := do(http.MethodPost, "/api/v1/reports")
+		require.Equal(t, http.StatusAccepted, rw.Code)
+		assert.Equal(t, OperationPending, op.Status)
+		location := rw.Header().Get("Location")
+		assert.Equal(t, "/api/v1/operations/"+op.ID, location)
+
+		<-started
+		op = poll(location, OperationRunning)
+		assert.Equal(t, 0.5, op.Progress)
+
+		release <- struct{}{}
+		op = poll(location, OperationSucceeded)
+		assert.Equal(t, 1.0, op.Progress)
+		assert.Equal(t, map[string]interface{}{"rows": float64(10)}, op.Result)
+		assert.Nil(t, op.Error)
+		assert.False(t, op.CompletedAt.IsZero())
+		assert.Equal(t, op.CompletedAt.Add(time.Minute), op.ExpiresAt)
+
+		rw, _ = do(http.MethodDelete, location)
+		assert.Equal(t, http.StatusNoContent, rw.Code)
+		rw, _ = do(http.MethodGet, location)
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+	})
+	t.Run("Cancel", func(t *testing.T) {
+		rw, _ := do(http.MethodPost, "/api/v1/reports")
+		location := rw.Header().Get("Location")
+		<-started
+
+		rw, op := do(http.MethodDelete, location)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, OperationCanceled, op.Status)
+
+		// the operation keeps its canceled status after the
+		// function returns.
+		time.Sleep(10 * time.Millisecond)
+		_, op = do(http.MethodGet, location)
+		assert.Equal(t, OperationCanceled, op.Status)
+		assert.Nil(t, op.Result)
+	})
+	t.Run("Failure", func(t *testing.T) {
+		id := manager.Start(ctx, func(ctx context.Context, progress func(float64)) (interface{}, error) {
+			return nil, ErrorResponse{StatusCode: http.StatusConflict, Message: "report exists"}
+		}).Data().(Operation).ID
+
+		op := poll("/api/v1/operations/"+id, OperationFailed)
+		require.NotNil(t, op.Error)
+		assert.Equal(t, http.StatusConflict, op.Error.StatusCode)
+		assert.Equal(t, "report exists", op.Error.Message)
+
+		id = manager.Start(ctx, func(ctx context.Context, progress func(float64)) (interface{}, error) {
+			panic("boom")
+		}).Data().(Operation).ID
+		op = poll("/api/v1/operations/"+id, OperationFailed)
+		assert.Equal(t, http.StatusInternalServerError, op.Error.StatusCode)
+		assert.Contains(t, op.Error.Message, "boom")
+	})
+	t.Run("Missing", func(t *testing.T) {
+		rw, _ := do(http.MethodGet, "/api/v1/operations/unknown")
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		rw, _ = do(http.MethodDelete, "/api/v1/operations/unknown")
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+	})
+	t.Run("Shutdown", func(t *testing.T) {
+		mctx, mcancel := context.WithCancel(ctx)
+		m := NewOperationManager(mctx, OperationOptions{})
+		resp := m.Start(context.Background(), func(ctx context.Context, progress func(float64)) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		assert.Empty(t, resp.(HeaderResponder).Header().Get("Location"))
+
+		mcancel()
+		id := resp.Data().(Operation).ID
+		require.Eventually(t, func() bool {
+			op, ok, err := m.Get(ctx, id)
+			return err == nil && ok && op.Status == OperationCanceled
+		}, time.Second, 5*time.Millisecond)
+	})
+	t.Run("Store", func(t *testing.T) {
+		store := NewInMemoryOperationStore(ctx).(*operationStore)
+		assert.Error(t, store.Put(ctx, Operation{}))
+
+		require.NoError(t, store.Put(ctx, Operation{ID: "a", ExpiresAt: time.Now().Add(-time.Second)}))
+		require.NoError(t, store.Put(ctx, Operation{ID: "b"}))
+
+		_, ok, err := store.Get(ctx, "a")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		store.clean()
+		assert.Len(t, store.ops, 1)
+
+		require.NoError(t, store.Delete(ctx, "b"))
+		require.NoError(t, store.Delete(ctx, "b"))
+		assert.Empty(t, store.ops)
+	})
+}
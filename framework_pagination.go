@@ -15,8 +15,15 @@ import (
 //
 // The pagination types and methods are
 type ResponsePages struct {
-	Next *Page
-	Prev *Page
+	Next  *Page
+	Prev  *Page
+	First *Page
+	Last  *Page
+
+	// TotalCount, when set, is the total number of items in the
+	// collection, which RouteHandlers write in the X-Total-Count
+	// header.
+	TotalCount *int
 }
 
 // GetLinks returns the strings for use in the links header
@@ -35,6 +42,14 @@ func (r *ResponsePages) GetLinks(route string) string {
 		links = append(links, r.Prev.GetLink(route))
 	}
 
+	if r.First != nil {
+		links = append(links, r.First.GetLink(route))
+	}
+
+	if r.Last != nil {
+		links = append(links, r.Last.GetLink(route))
+	}
+
 	return strings.Join(links, ",")
 }
 
@@ -42,7 +57,7 @@ func (r *ResponsePages) GetLinks(route string) string {
 // pagination metadata are consistent.
 func (r *ResponsePages) Validate() error {
 	catcher := &erc.Collector{}
-	for _, p := range []*Page{r.Next, r.Prev} {
+	for _, p := range []*Page{r.Next, r.Prev, r.First, r.Last} {
 		if p == nil {
 			continue
 		}
@@ -50,6 +65,10 @@ func (r *ResponsePages) Validate() error {
 		catcher.Push(p.Validate())
 	}
 
+	if r.TotalCount != nil && *r.TotalCount < 0 {
+		catcher.Push(errors.New("total count cannot be negative"))
+	}
+
 	return catcher.Resolve()
 }
 
@@ -57,13 +76,18 @@ func (r *ResponsePages) Validate() error {
 // build the page, the route must have access to the full realized
 // path, including any extra query parameters, to make it possible to
 // build the metadata.
+//
+// Pages that specify an OffsetQueryParam use offsets rather than keys
+// to identify pages, and do not require a key.
 type Page struct {
-	BaseURL         string
-	KeyQueryParam   string
-	LimitQueryParam string
+	BaseURL          string
+	KeyQueryParam    string
+	LimitQueryParam  string
+	OffsetQueryParam string
 
 	Key      string
 	Limit    int
+	Offset   int
 	Relation string
 
 	url *url.URL
@@ -79,7 +103,9 @@ func (p *Page) Validate() error {
 		errs = append(errs, "base url not specified")
 	}
 
-	if p.KeyQueryParam == "" {
+	offsets := p.OffsetQueryParam != ""
+
+	if p.KeyQueryParam == "" && !offsets {
 		errs = append(errs, "key query parameter name not specified")
 	}
 
@@ -91,8 +117,13 @@ func (p *Page) Validate() error {
 		errs = append(errs, "page relation not specified")
 	}
 
-	if p.Key == "" {
-		errs = append(errs, "limit not specified")
+	// the first page of a keyed collection does not have a key.
+	if p.Key == "" && !offsets && p.Relation != "first" {
+		errs = append(errs, "key not specified")
+	}
+
+	if p.Offset < 0 {
+		errs = append(errs, "offset cannot be negative")
 	}
 
 	_, err := url.Parse(p.BaseURL)
@@ -117,7 +148,14 @@ func (p *Page) GetLink(route string) string {
 	}
 
 	q := url.Query()
-	q.Set(p.KeyQueryParam, p.Key)
+	switch {
+	case p.OffsetQueryParam != "":
+		q.Set(p.OffsetQueryParam, fmt.Sprintf("%d", p.Offset))
+	case p.Key == "" && p.KeyQueryParam != "":
+		q.Del(p.KeyQueryParam)
+	default:
+		q.Set(p.KeyQueryParam, p.Key)
+	}
 
 	if p.Limit != 0 {
 		q.Set(p.LimitQueryParam, fmt.Sprintf("%d", p.Limit))
@@ -0,0 +1,208 @@
+package gimlet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+)
+
+// ProblemContentType is the media type of RFC 7807 problem details
+// documents.
+const ProblemContentType = "application/problem+json"
+
+// ProblemDetails is an RFC 7807 problem details document, which
+// describes an error in a machine-readable form. ProblemDetails
+// implements the error interface, and routes may return it, as
+// ErrorResponse, to control the members of the document.
+//
+// JSON responders always write ProblemDetails values with the
+// "application/problem+json" content type. Extension members are
+// written alongside the standard members of the document.
+type ProblemDetails struct {
+	Type       string                 `bson:"type,omitempty" json:"type,omitempty" yaml:"type,omitempty"`
+	Title      string                 `bson:"title,omitempty" json:"title,omitempty" yaml:"title,omitempty"`
+	Status     int                    `bson:"status,omitempty" json:"status,omitempty" yaml:"status,omitempty"`
+	Detail     string                 `bson:"detail,omitempty" json:"detail,omitempty" yaml:"detail,omitempty"`
+	Instance   string                 `bson:"instance,omitempty" json:"instance,omitempty" yaml:"instance,omitempty"`
+	Extensions map[string]interface{} `bson:",inline" json:"-" yaml:",inline"`
+}
+
+var problemMembers = []string{"type", "title", "status", "detail", "instance"}
+
+func (p ProblemDetails) Error() string {
+	msg := p.Detail
+	if msg == "" {
+		msg = p.Title
+	}
+
+	return fmt.Sprintf("%d (%s): %s", p.Status, http.StatusText(p.Status), msg)
+}
+
+// MarshalJSON writes the extension members of the problem at the top
+// level of the document.
+func (p ProblemDetails) MarshalJSON() ([]byte, error) {
+	type members ProblemDetails
+
+	out, err := json.Marshal(members(p))
+	if err != nil || len(p.Extensions) == 0 {
+		return out, err
+	}
+
+	doc := map[string]interface{}{}
+	for k, v := range p.Extensions {
+		doc[k] = v
+	}
+
+	// the standard members take precedence over extensions with
+	// the same name.
+	std := map[string]interface{}{}
+	if err := json.Unmarshal(out, &std); err != nil {
+		return nil, err
+	}
+	for k, v := range std {
+		doc[k] = v
+	}
+
+	return json.Marshal(doc)
+}
+
+// UnmarshalJSON reads the standard members of the problem, storing
+// all other members in Extensions.
+func (p *ProblemDetails) UnmarshalJSON(in []byte) error {
+	type members ProblemDetails
+
+	std := members{}
+	if err := json.Unmarshal(in, &std); err != nil {
+		return err
+	}
+
+	doc := map[string]interface{}{}
+	if err := json.Unmarshal(in, &doc); err != nil {
+		return err
+	}
+	for _, k := range problemMembers {
+		delete(doc, k)
+	}
+
+	*p = ProblemDetails(std)
+	p.Extensions = nil
+	if len(doc) > 0 {
+		p.Extensions = doc
+	}
+
+	return nil
+}
+
+// Problem converts the error response into a problem details
+// document, with the "about:blank" type. Fields errors are reported
+// in the "errors" extension member.
+func (e ErrorResponse) Problem() ProblemDetails {
+	p := ProblemDetails{
+		Type:   "about:blank",
+		Title:  http.StatusText(e.StatusCode),
+		Status: e.StatusCode,
+		Detail: e.Message,
+	}
+
+	if len(e.Fields) > 0 {
+		p.Extensions = map[string]interface{}{"errors": e.Fields}
+	}
+
+	return p
+}
+
+// UseProblemDetails enables the problem details mode for all routes
+// in the application. In this mode, responders with the JSON format
+// write ErrorResponse values, such as the responses of
+// MakeJSONErrorResponder, NewJSONErrorResponse and the errors that
+// gimlet produces when handling routes, as RFC 7807 problem details
+// documents. The instance member of these documents holds the ID of
+// the request.
+//
+// The mode is disabled by default, and ErrorResponse values retain
+// their own shape. Use APIRoute.ProblemDetails to enable the mode
+// for individual routes.
+func (a *APIApp) UseProblemDetails() *APIApp {
+	a.problemDetails = true
+	return a
+}
+
+// ProblemDetails enables the problem details mode, as described by
+// APIApp.UseProblemDetails, for the route.
+func (r *APIRoute) ProblemDetails() *APIRoute {
+	r.problemDetails = true
+	return r
+}
+
+func withProblemDetails(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), problemDetailsKey, true)))
+	})
+}
+
+func usingProblemDetails(ctx context.Context) bool {
+	enabled, _ := ctx.Value(problemDetailsKey).(bool)
+	return enabled
+}
+
+// getProblem returns the problem details document for response data
+// that should be written as a problem. ErrorResponse values are only
+// problems when converting errors.
+func getProblem(data interface{}, convertErrors bool) (ProblemDetails, bool) {
+	switch d := data.(type) {
+	case ProblemDetails:
+		return d, true
+	case *ProblemDetails:
+		if d != nil {
+			return *d, true
+		}
+	case ErrorResponse:
+		if convertErrors {
+			return d.Problem(), true
+		}
+	case *ErrorResponse:
+		if d != nil && convertErrors {
+			return d.Problem(), true
+		}
+	}
+
+	return ProblemDetails{}, false
+}
+
+// WriteProblemResponse writes a problem details document to the body
+// of an HTTP response, with the "application/problem+json" content
+// type. The status of the problem defaults to the code.
+func WriteProblemResponse(w http.ResponseWriter, code int, problem ProblemDetails) {
+	if problem.Status == 0 {
+		problem.Status = code
+	}
+
+	response, err := json.MarshalIndent(problem, "", "  ")
+	if err != nil {
+		grip.Debug(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(code)
+
+	if _, err = w.Write(append(response, '\n')); err != nil {
+		grip.Warning(errors.Wrap(err, "problem writing problem details response"))
+	}
+}
+
+// writeProblem writes the problem for a request, identifying the
+// request in the problem's instance member.
+func writeProblem(ctx context.Context, w http.ResponseWriter, code int, problem ProblemDetails) {
+	if id := GetRequestID(ctx); problem.Instance == "" && id > 0 {
+		problem.Instance = strconv.Itoa(id)
+	}
+
+	WriteProblemResponse(w, code, problem)
+}
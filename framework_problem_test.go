@@ -0,0 +1,142 @@
+package gimlet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestProblemDetails(t *testing.T) {
+	validation := ErrorResponse{
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "invalid request",
+		Fields:     []FieldError{{Field: "limit", Source: ParamSourceQuery, Message: "must be positive"}},
+	}
+
+	t.Run("DefaultShape", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, MakeJSONErrorResponder(validation))
+		assert.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+		assert.Equal(t, JSON.ContentType(), rw.Header().Get("Content-Type"))
+
+		out := ErrorResponse{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, "invalid request", out.Message)
+		assert.Len(t, out.Fields, 1)
+	})
+	t.Run("Mode", func(t *testing.T) {
+		handler := withProblemDetails(handleHandler(respondWith(MakeJSONErrorResponder(errors.Wrap(validation, "wrapped")))))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, setRequestID(httptest.NewRequest(http.MethodGet, "/", nil), 42))
+
+		assert.Equal(t, http.StatusUnprocessableEntity, rw.Code)
+		assert.Equal(t, ProblemContentType, rw.Header().Get("Content-Type"))
+
+		doc := map[string]interface{}{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &doc))
+		assert.Equal(t, "about:blank", doc["type"])
+		assert.Equal(t, "Unprocessable Entity", doc["title"])
+		assert.EqualValues(t, http.StatusUnprocessableEntity, doc["status"])
+		assert.Equal(t, "invalid request", doc["detail"])
+		assert.Equal(t, "42", doc["instance"])
+		require.Len(t, doc["errors"], 1)
+		assert.Equal(t, "limit", doc["errors"].([]interface{})[0].(map[string]interface{})["field"])
+
+		// other formats retain the existing shape.
+		rw = httptest.NewRecorder()
+		WriteResponse(rw, MakeYAMLErrorResponder(validation))
+		assert.Equal(t, YAML.ContentType(), rw.Header().Get("Content-Type"))
+		out := ErrorResponse{}
+		require.NoError(t, yaml.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, "invalid request", out.Message)
+	})
+	t.Run("HandlerErrors", func(t *testing.T) {
+		handler := withProblemDetails(handleHandler(&testHandler{}))
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Equal(t, ProblemContentType, rw.Header().Get("Content-Type"))
+		assert.Contains(t, rw.Body.String(), "undefined response")
+		assert.NotContains(t, rw.Body.String(), "instance")
+	})
+	t.Run("Options", func(t *testing.T) {
+		typed := Typed(func(ctx context.Context, in struct{}) (struct{}, error) { return struct{}{}, validation })
+
+		app := NewApp()
+		app.NoVersions = true
+		app.AddRoute("/default").Get().RouteHandler(typed)
+		app.AddRoute("/route").Get().ProblemDetails().RouteHandler(typed)
+
+		problems := NewApp().UseProblemDetails()
+		problems.SetPrefix("problems")
+		problems.NoVersions = true
+		problems.AddRoute("/app").Get().RouteHandler(typed)
+
+		h, err := MergeApplications(app, problems)
+		require.NoError(t, err)
+
+		for path, contentType := range map[string]string{
+			"/default":      JSON.ContentType(),
+			"/route":        ProblemContentType,
+			"/problems/app": ProblemContentType,
+		} {
+			rw := httptest.NewRecorder()
+			h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, path, nil))
+			assert.Equal(t, http.StatusUnprocessableEntity, rw.Code, path)
+			assert.Equal(t, contentType, rw.Header().Get("Content-Type"), path)
+		}
+
+		merged := NewApp()
+		merged.NoVersions = true
+		require.NoError(t, merged.Merge(problems))
+		h, err = merged.Handler()
+		require.NoError(t, err)
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/problems/app", nil))
+		assert.Equal(t, ProblemContentType, rw.Header().Get("Content-Type"))
+	})
+	t.Run("Explicit", func(t *testing.T) {
+		problem := ProblemDetails{
+			Type:       "https://example.com/problems/out-of-credit",
+			Title:      "You do not have enough credit.",
+			Detail:     "Your current balance is 30, but that costs 50.",
+			Instance:   "/account/12345/msgs/abc",
+			Extensions: map[string]interface{}{"balance": 30, "status": 999},
+		}
+
+		resp := MakeJSONErrorResponder(problem)
+		assert.Equal(t, http.StatusBadRequest, resp.Status())
+
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, resp)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Equal(t, ProblemContentType, rw.Header().Get("Content-Type"))
+
+		out := ProblemDetails{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, problem.Type, out.Type)
+		assert.Equal(t, problem.Instance, out.Instance)
+		assert.Equal(t, http.StatusBadRequest, out.Status)
+		assert.Equal(t, map[string]interface{}{"balance": float64(30)}, out.Extensions)
+
+		resp = MakeTextErrorResponder(ProblemDetails{Status: http.StatusConflict, Title: "conflict"})
+		assert.Equal(t, http.StatusConflict, resp.Status())
+		assert.Equal(t, "409 (Conflict): conflict", resp.Data())
+	})
+	t.Run("Conversion", func(t *testing.T) {
+		problem := ErrorResponse{StatusCode: http.StatusNotFound, Message: "missing"}.Problem()
+		assert.Equal(t, ProblemDetails{Type: "about:blank", Title: "Not Found", Status: http.StatusNotFound, Detail: "missing"}, problem)
+		assert.Equal(t, "404 (Not Found): missing", problem.Error())
+
+		out, err := json.Marshal(problem)
+		require.NoError(t, err)
+		assert.Equal(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"missing"}`, string(out))
+	})
+}
@@ -1,6 +1,7 @@
 package gimlet
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -50,6 +51,31 @@ type Responder interface {
 }
 
 func WriteResponse(rw http.ResponseWriter, resp Responder) {
+	writeResponder(context.Background(), rw, resp)
+}
+
+func writeResponder(ctx context.Context, rw http.ResponseWriter, resp Responder) {
+	if hr, ok := resp.(HeaderResponder); ok {
+		writeHeaders(rw, hr)
+		defer writeTrailers(rw, hr)
+	}
+
+	if cr, ok := resp.(ConditionalResponder); ok {
+		writeValidators(rw.Header(), cr.ETag(), cr.LastModified())
+	}
+
+	if stream, ok := resp.(StreamResponder); ok {
+		writeStream(ctx, rw, stream)
+		return
+	}
+
+	if resp.Format() == JSON {
+		if problem, ok := getProblem(resp.Data(), usingProblemDetails(ctx)); ok {
+			writeProblem(ctx, rw, resp.Status(), problem)
+			return
+		}
+	}
+
 	// Write the response, based on the format specified.
 	switch resp.Format() {
 	case JSON:
@@ -64,6 +90,16 @@ func WriteResponse(rw http.ResponseWriter, resp Responder) {
 		WriteBinaryResponse(rw, resp.Status(), resp.Data())
 	case CSV:
 		WriteCSVResponse(rw, resp.Status(), resp.Data())
+	case NDJSON:
+		WriteNDJSONResponse(rw, resp.Status(), resp.Data())
+	case SSE:
+		WriteSSEResponse(rw, resp.Status(), resp.Data())
+	case BSON:
+		WriteBSONResponse(rw, resp.Status(), resp.Data())
+	case MSGPACK:
+		WriteMsgpackResponse(rw, resp.Status(), resp.Data())
+	case XML:
+		WriteXMLResponse(rw, resp.Status(), resp.Data())
 	}
 }
 
@@ -81,6 +117,7 @@ func NewResponseBuilder() Responder {
 }
 
 type responseBuilder struct {
+	responseHeaders
 	data   []interface{}
 	format OutputFormat
 	status int
@@ -176,6 +213,7 @@ func NewBasicResponder(s int, f OutputFormat, data interface{}) (Responder, erro
 }
 
 type responderImpl struct {
+	responseHeaders
 	data   interface{}
 	format OutputFormat
 	status int
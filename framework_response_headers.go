@@ -0,0 +1,103 @@
+package gimlet
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// HeaderResponder is implemented by Responders that set the headers,
+// cookies, or trailers of their response, such as Location,
+// Cache-Control or Set-Cookie. WriteResponse, and RouteHandlers,
+// write these values with the response. The responders that gimlet
+// constructs implement HeaderResponder, and users can access these
+// methods with a type assertion:
+//
+//	resp := gimlet.NewJSONResponse(doc)
+//	resp.(gimlet.HeaderResponder).Header().Set("Cache-Control", "no-store")
+type HeaderResponder interface {
+	Responder
+
+	// Header returns the headers of the response, which callers
+	// may modify. The content type of the response is determined
+	// by its format, and replaces any Content-Type header.
+	Header() http.Header
+
+	// Trailer returns the trailers of the response, which are
+	// sent after the body. Callers must add the trailers before
+	// gimlet writes the response.
+	Trailer() http.Header
+
+	// Cookies returns the cookies that the response sets, and
+	// AddCookie adds a cookie to the response, returning an error
+	// if the cookie is not valid.
+	Cookies() []*http.Cookie
+	AddCookie(*http.Cookie) error
+}
+
+// responseHeaders implements the HeaderResponder and
+// ConditionalResponder methods for the responder implementations.
+type responseHeaders struct {
+	header       http.Header
+	trailer      http.Header
+	cookies      []*http.Cookie
+	etag         string
+	lastModified time.Time
+}
+
+func (r *responseHeaders) Header() http.Header {
+	if r.header == nil {
+		r.header = http.Header{}
+	}
+
+	return r.header
+}
+
+func (r *responseHeaders) Trailer() http.Header {
+	if r.trailer == nil {
+		r.trailer = http.Header{}
+	}
+
+	return r.trailer
+}
+
+func (r *responseHeaders) Cookies() []*http.Cookie { return r.cookies }
+
+func (r *responseHeaders) AddCookie(c *http.Cookie) error {
+	if c == nil {
+		return errors.New("cannot add nil cookie to responder")
+	}
+
+	if err := c.Valid(); err != nil {
+		return errors.Wrapf(err, "cannot add invalid cookie '%s' to responder", c.Name)
+	}
+
+	r.cookies = append(r.cookies, c)
+	return nil
+}
+
+// writeHeaders adds the headers and cookies of the response to the
+// writer, and declares its trailers. Callers must write the headers
+// before the status.
+func writeHeaders(rw http.ResponseWriter, resp HeaderResponder) {
+	for key, values := range resp.Header() {
+		rw.Header()[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+
+	for _, c := range resp.Cookies() {
+		http.SetCookie(rw, c)
+	}
+
+	for key := range resp.Trailer() {
+		rw.Header().Add("Trailer", http.CanonicalHeaderKey(key))
+	}
+}
+
+// writeTrailers sets the values of the trailers of the response,
+// which writeHeaders declared, after the body.
+func writeTrailers(rw http.ResponseWriter, resp HeaderResponder) {
+	for key, values := range resp.Trailer() {
+		rw.Header()[http.CanonicalHeaderKey(key)] = append([]string(nil), values...)
+	}
+}
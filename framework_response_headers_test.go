@@ -0,0 +1,73 @@
+package gimlet
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderResponder(t *testing.T) {
+	builder := NewResponseBuilder()
+	basic, err := NewBasicResponder(http.StatusCreated, JSON, map[string]string{"id": "one"})
+	require.NoError(t, err)
+
+	for name, resp := range map[string]Responder{
+		"Builder":    builder,
+		"Basic":      basic,
+		"Simple":     NewTextResponse("hello"),
+		"Error":      MakeJSONErrorResponder(ErrorResponse{StatusCode: http.StatusConflict, Message: "conflict"}),
+		"Stream":     NewNDJSONStreamResponse(streamTestItems(2, -1)),
+		"Negotiated": Negotiate(NewJSONResponse("hello"), JSON, YAML),
+	} {
+		t.Run(name, func(t *testing.T) {
+			hr, ok := resp.(HeaderResponder)
+			require.True(t, ok)
+
+			hr.Header().Set("Location", "/things/one")
+			hr.Header().Add("cache-control", "no-store")
+			hr.Trailer().Set("X-Checksum", "abc")
+			require.NoError(t, hr.AddCookie(&http.Cookie{Name: "session", Value: "token", HttpOnly: true}))
+
+			rw := httptest.NewRecorder()
+			handleHandler(respondWith(resp))(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, resp.Status(), rw.Code)
+
+			result := rw.Result()
+			assert.Equal(t, "/things/one", result.Header.Get("Location"))
+			assert.Equal(t, "no-store", result.Header.Get("Cache-Control"))
+			assert.Equal(t, "session=token; HttpOnly", result.Header.Get("Set-Cookie"))
+			assert.Equal(t, "abc", result.Trailer.Get("X-Checksum"))
+			assert.NotEmpty(t, result.Header.Get("Content-Type"))
+		})
+	}
+
+	t.Run("WriteResponse", func(t *testing.T) {
+		resp := NewJSONResponse("hello")
+		resp.(HeaderResponder).Header().Set("Content-Type", "text/plain")
+		resp.(HeaderResponder).Header().Set("ETag", `"v1"`)
+
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, resp)
+		assert.Equal(t, `"v1"`, rw.Header().Get("ETag"))
+		assert.Equal(t, JSON.ContentType(), rw.Header().Get("Content-Type"))
+	})
+	t.Run("StreamTrailers", func(t *testing.T) {
+		resp := NewNDJSONStreamResponse(streamTestItems(3, 1))
+		resp.(HeaderResponder).Trailer().Set("X-Checksum", "abc")
+
+		rw := httptest.NewRecorder()
+		WriteResponse(rw, resp)
+		result := rw.Result()
+		assert.Equal(t, "abc", result.Trailer.Get("X-Checksum"))
+		assert.Contains(t, result.Trailer.Get(StreamErrorTrailer), "source failed")
+	})
+	t.Run("InvalidCookies", func(t *testing.T) {
+		hr := NewJSONResponse("hello").(HeaderResponder)
+		assert.Error(t, hr.AddCookie(nil))
+		assert.Error(t, hr.AddCookie(&http.Cookie{Name: "bad name", Value: "v"}))
+		assert.Empty(t, hr.Cookies())
+	})
+}
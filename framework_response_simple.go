@@ -8,26 +8,51 @@ import (
 
 func newResponder(data interface{}, code int, of OutputFormat) Responder {
 	switch in := data.(type) {
+	case ProblemDetails, *ProblemDetails:
+		problem, _ := getProblem(in, false)
+		if http.StatusText(problem.Status) == "" {
+			problem.Status = code
+		}
+
+		if of == TEXT {
+			return &responderImpl{
+				data:   problem.Error(),
+				status: problem.Status,
+				format: of,
+			}
+		}
+		return &responderImpl{
+			data:   problem,
+			status: problem.Status,
+			format: of,
+		}
 	case error:
 		var eresp ErrorResponse
 		switch err := errors.Cause(in).(type) {
+		case ProblemDetails, *ProblemDetails:
+			return newResponder(err, code, of)
 		case *ErrorResponse:
+			eresp = *err
 			if http.StatusText(eresp.StatusCode) == "" {
 				eresp.StatusCode = code
 			}
-
-			eresp = *err
 		case ErrorResponse:
+			eresp = err
 			if http.StatusText(eresp.StatusCode) == "" {
 				eresp.StatusCode = code
 			}
-
-			eresp = err
 		default:
 			eresp = ErrorResponse{
 				StatusCode: code,
 				Message:    err.Error(),
 			}
+
+			if status, msg, ok := lookupErrorStatus(in); ok {
+				eresp.StatusCode = status
+				if msg != "" {
+					eresp.Message = msg
+				}
+			}
 		}
 
 		if of == TEXT {
@@ -110,3 +135,39 @@ func NewYAMLErrorResponse(data interface{}) Responder {
 func NewYAMLInternalErrorResponse(data interface{}) Responder {
 	return newResponder(data, http.StatusInternalServerError, YAML)
 }
+
+func NewBSONResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusOK, BSON)
+}
+
+func NewBSONErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusBadRequest, BSON)
+}
+
+func NewBSONInternalErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusInternalServerError, BSON)
+}
+
+func NewMsgpackResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusOK, MSGPACK)
+}
+
+func NewMsgpackErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusBadRequest, MSGPACK)
+}
+
+func NewMsgpackInternalErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusInternalServerError, MSGPACK)
+}
+
+func NewXMLResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusOK, XML)
+}
+
+func NewXMLErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusBadRequest, XML)
+}
+
+func NewXMLInternalErrorResponse(data interface{}) Responder {
+	return newResponder(data, http.StatusInternalServerError, XML)
+}
@@ -0,0 +1,155 @@
+package gimlet
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+
+	"github.com/tychoish/grip/message"
+)
+
+// StreamErrorTrailer is the HTTP trailer that streaming responders
+// use to report errors that occur after they have written the status
+// and the beginning of the response body.
+const StreamErrorTrailer = "X-Stream-Error"
+
+// StreamResponder is implemented by Responders that write their body
+// incrementally rather than holding the entire response in
+// memory. WriteResponse, and RouteHandlers, write StreamResponders by
+// calling Stream, regardless of their format.
+type StreamResponder interface {
+	Responder
+
+	// Stream writes the status, headers, and body of the response
+	// to the writer, stopping when the context is canceled. Once
+	// Stream has written the status, it cannot report errors to
+	// the client except in the body or in the StreamErrorTrailer
+	// trailer, and returns these errors to the caller.
+	Stream(context.Context, http.ResponseWriter) error
+}
+
+// writeStream writes a streaming response, logging errors that it
+// cannot report to the client.
+func writeStream(ctx context.Context, rw http.ResponseWriter, resp StreamResponder) {
+	if err := resp.Stream(ctx, rw); err != nil {
+		GetLogger(ctx).Error(message.WrapError(err, message.Fields{
+			"message": "problem writing streaming response",
+			"format":  resp.Format().String(),
+		}))
+	}
+}
+
+// streamResponder provides the Responder methods shared by the
+// streaming responders, which hold a data source rather than data.
+type streamResponder struct {
+	responseHeaders
+	source interface{}
+	format OutputFormat
+	status int
+	pages  *ResponsePages
+}
+
+func (r *streamResponder) Data() interface{}     { return r.source }
+func (r *streamResponder) Format() OutputFormat  { return r.format }
+func (r *streamResponder) Status() int           { return r.status }
+func (r *streamResponder) Pages() *ResponsePages { return r.pages }
+
+func (r *streamResponder) Validate() error {
+	if r.source == nil {
+		return errors.New("streaming responder has no data source")
+	}
+
+	if http.StatusText(r.status) == "" {
+		return fmt.Errorf("%d is not a valid HTTP status", r.status)
+	}
+
+	return nil
+}
+
+func (r *streamResponder) AddData(interface{}) error {
+	return errors.New("cannot add data to a streaming responder")
+}
+
+func (r *streamResponder) SetFormat(o OutputFormat) error {
+	if o != r.format {
+		return fmt.Errorf("cannot change the format of a %s stream to %s", r.format, o)
+	}
+
+	return nil
+}
+
+func (r *streamResponder) SetStatus(s int) error {
+	if http.StatusText(s) == "" {
+		return fmt.Errorf("%d is not a valid HTTP status", s)
+	}
+
+	r.status = s
+	return nil
+}
+
+func (r *streamResponder) SetPages(p *ResponsePages) error {
+	if err := p.Validate(); err != nil {
+		return fmt.Errorf("cannot set an invalid page definition: %s", err.Error())
+	}
+
+	r.pages = p
+	return nil
+}
+
+// flush flushes buffered data to the client, if the writer supports
+// flushing.
+func flush(rw http.ResponseWriter) error {
+	if err := http.NewResponseController(rw).Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	return nil
+}
+
+// startStream writes the status and headers of a streaming response,
+// declaring the trailer that reports errors.
+func startStream(rw http.ResponseWriter, format OutputFormat, status int) {
+	rw.Header().Set("Content-Type", format.ContentType())
+	rw.Header().Add("Trailer", StreamErrorTrailer)
+	rw.WriteHeader(status)
+}
+
+// streamErrorResponse converts an error that a stream encounters
+// before writing any data into an error response.
+func streamErrorResponse(err error) ErrorResponse {
+	var eresp ErrorResponse
+	if errors.As(err, &eresp) && http.StatusText(eresp.StatusCode) != "" {
+		return eresp
+	}
+
+	return ErrorResponse{StatusCode: http.StatusInternalServerError, Message: err.Error()}
+}
+
+// reportStreamError records an error that occurred after the stream
+// began in the stream's error trailer, which the stream must declare
+// before writing the status.
+func reportStreamError(rw http.ResponseWriter, err error) {
+	rw.Header().Set(StreamErrorTrailer, err.Error())
+}
+
+// channelSeq converts a channel into a sequence, which ends when the
+// channel is closed or produces the context's error when the context
+// is canceled.
+func channelSeq[T any](ctx context.Context, ch <-chan T) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for {
+			select {
+			case <-ctx.Done():
+				var zero T
+				yield(zero, ctx.Err())
+				return
+			case item, ok := <-ch:
+				if !ok || !yield(item, nil) {
+					return
+				}
+			}
+		}
+	}
+}
@@ -133,7 +133,7 @@ func (s *ResponderSuite) TestPages() {
 
 	for _, good := range []*ResponsePages{
 		{},
-		{nil, nil},
+		{Next: nil, Prev: nil},
 	} {
 		s.NoError(s.resp.SetPages(good))
 		s.NotNil(s.resp.Pages())
@@ -45,6 +45,43 @@ func (m *mockHandler) Run(ctx context.Context) Responder {
 	return &responderImpl{}
 }
 
+// testHandler is a RouteHandler defined by functions, for tests that
+// only need to control the results of Parse and Run. As with
+// mockHandler, Factory returns a new handler for each request.
+type testHandler struct {
+	parse func(context.Context, *http.Request) error
+	run   func(context.Context) Responder
+}
+
+func (h *testHandler) Factory() RouteHandler {
+	return &testHandler{
+		parse: h.parse,
+		run:   h.run,
+	}
+}
+
+func (h *testHandler) Parse(ctx context.Context, r *http.Request) error {
+	if h.parse == nil {
+		return nil
+	}
+
+	return h.parse(ctx, r)
+}
+
+func (h *testHandler) Run(ctx context.Context) Responder {
+	if h.run == nil {
+		return nil
+	}
+
+	return h.run(ctx)
+}
+
+// respondWith returns a test handler that responds with the
+// responder.
+func respondWith(resp Responder) *testHandler {
+	return &testHandler{run: func(context.Context) Responder { return resp }}
+}
+
 type mockResponder struct {
 	responderImpl
 
@@ -226,4 +263,9 @@ func (s *HandlerSuite) TestGetErrorHelper() {
 	out = newResponder(in4, 9001, JSON)
 	s.Equal(9001, out.Status())
 	s.Equal("wtf", out.Data().(ErrorResponse).Message)
+
+	in5 := ErrorResponse{Message: "nostatus"}
+	out = newResponder(in5, http.StatusBadRequest, JSON)
+	s.Equal(http.StatusBadRequest, out.Status())
+	s.Equal(http.StatusBadRequest, out.Data().(ErrorResponse).StatusCode)
 }
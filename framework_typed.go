@@ -0,0 +1,100 @@
+package gimlet
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+)
+
+// TypedFunc is the signature of handler functions that Typed
+// converts into RouteHandlers.
+type TypedFunc[In, Out any] func(context.Context, In) (Out, error)
+
+// Typed produces a RouteHandler from a function that receives and
+// returns concrete types, removing the need to implement Factory,
+// Parse, and Run for most routes:
+//
+//	app.AddRoute("/widgets/{id}").Version(1).Put().RouteHandler(
+//		gimlet.Typed(func(ctx context.Context, in UpdateWidget) (*Widget, error) {
+//			...
+//		}))
+//
+// For every request, the handler decodes the request body, if any,
+// into a new In value using GetBody. When In is a struct (or a
+// pointer to a struct) the handler then binds tagged path, query and
+// header parameters using BindParams, which override values from the
+// body. Decoding failures produce error responses (e.g. 400 or 415).
+//
+// Errors returned by the function become error responses: the
+// status of ErrorResponse errors is preserved, and all other errors
+// produce 500 responses. Out values are written as JSON with a 200
+// status, unless Out implements Responder, in which case the
+// handler writes the responder as is.
+func Typed[In, Out any](fn TypedFunc[In, Out]) RouteHandler {
+	return &typedHandler[In, Out]{fn: fn}
+}
+
+type typedHandler[In, Out any] struct {
+	fn    TypedFunc[In, Out]
+	input In
+}
+
+func (h *typedHandler[In, Out]) Factory() RouteHandler {
+	return &typedHandler[In, Out]{fn: h.fn}
+}
+
+func (h *typedHandler[In, Out]) Parse(ctx context.Context, r *http.Request) error {
+	if err := decodeTypedBody(r, &h.input); err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(&h.input).Elem()
+	if target.Kind() == reflect.Ptr {
+		if target.IsNil() && target.Type().Elem().Kind() == reflect.Struct {
+			target.Set(reflect.New(target.Type().Elem()))
+		}
+		target = target.Elem()
+	}
+
+	if target.Kind() == reflect.Struct {
+		return BindParams(r, target.Addr().Interface())
+	}
+
+	return nil
+}
+
+func (h *typedHandler[In, Out]) Run(ctx context.Context) Responder {
+	out, err := h.fn(ctx, h.input)
+	if err != nil {
+		return newResponder(err, http.StatusInternalServerError, JSON)
+	}
+
+	if resp, ok := interface{}(out).(Responder); ok && resp != nil {
+		return resp
+	}
+
+	return NewJSONResponse(out)
+}
+
+// decodeTypedBody decodes the body of the request, if it has a
+// body, into the value.
+func decodeTypedBody(r *http.Request, data interface{}) error {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil
+	}
+
+	body := bufio.NewReader(r.Body)
+	if _, err := body.Peek(1); err == io.EOF {
+		return nil
+	}
+	r.Body = readCloser{Reader: body, Closer: r.Body}
+
+	return GetBody(r, data)
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
@@ -0,0 +1,109 @@
+package gimlet
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedTestInput struct {
+	ID    int    `json:"-" path:"id" validate:"min=1"`
+	Name  string `json:"name"`
+	Limit int    `json:"limit" query:"limit" default:"5"`
+}
+
+type typedTestOutput struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Limit int    `json:"limit"`
+}
+
+func TestTypedHandler(t *testing.T) {
+	app := NewApp()
+	app.AddRoute("/widgets/{id}").Version(1).Put().RouteHandler(Typed(func(ctx context.Context, in typedTestInput) (*typedTestOutput, error) {
+		switch in.Name {
+		case "missing":
+			return nil, ErrorResponse{StatusCode: http.StatusNotFound, Message: "no such widget"}
+		case "broken":
+			return nil, errors.New("broken widget")
+		}
+		return &typedTestOutput{ID: in.ID, Name: in.Name, Limit: in.Limit}, nil
+	}))
+	app.AddRoute("/accept").Version(1).Post().RouteHandler(Typed(func(ctx context.Context, in *typedTestInput) (Responder, error) {
+		return NewTextResponse("accepted " + in.Name), nil
+	}))
+	app.AddRoute("/count").Version(1).Post().RouteHandler(Typed(func(ctx context.Context, in []int) (int, error) {
+		return len(in), nil
+	}))
+
+	h, err := app.Handler()
+	require.NoError(t, err)
+
+	serve := func(method, path, body string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rw
+	}
+
+	t.Run("BodyAndParams", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/3?limit=20", `{"name": "gear", "limit": 2}`)
+		require.Equal(t, http.StatusOK, rw.Code)
+		out := typedTestOutput{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, typedTestOutput{ID: 3, Name: "gear", Limit: 20}, out)
+	})
+	t.Run("EmptyBody", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/3", "")
+		require.Equal(t, http.StatusOK, rw.Code)
+		out := typedTestOutput{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, typedTestOutput{ID: 3, Limit: 5}, out)
+	})
+	t.Run("InvalidBody", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/3", `{"name":`)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		assert.Contains(t, rw.Body.String(), "problem parsing JSON")
+	})
+	t.Run("InvalidParams", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/0", `{"name": "gear"}`)
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+		resp := ErrorResponse{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+		require.Len(t, resp.Fields, 1)
+		assert.Equal(t, "id", resp.Fields[0].Field)
+	})
+	t.Run("ErrorResponse", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/3", `{"name": "missing"}`)
+		assert.Equal(t, http.StatusNotFound, rw.Code)
+		assert.Contains(t, rw.Body.String(), "no such widget")
+	})
+	t.Run("Error", func(t *testing.T) {
+		rw := serve(http.MethodPut, "/v1/widgets/3", `{"name": "broken"}`)
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+		assert.Contains(t, rw.Body.String(), "broken widget")
+	})
+	t.Run("Responder", func(t *testing.T) {
+		rw := serve(http.MethodPost, "/v1/accept", `{"name": "gear"}`)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "accepted gear", rw.Body.String())
+	})
+	t.Run("NonStructInput", func(t *testing.T) {
+		rw := serve(http.MethodPost, "/v1/count", `[1, 2, 3]`)
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "3", strings.TrimSpace(rw.Body.String()))
+	})
+	t.Run("Factory", func(t *testing.T) {
+		handler := Typed(func(ctx context.Context, in typedTestInput) (string, error) { return in.Name, nil })
+		first := handler.Factory().(*typedHandler[typedTestInput, string])
+		first.input.Name = "first"
+		second := handler.Factory().(*typedHandler[typedTestInput, string])
+		assert.Empty(t, second.input.Name)
+	})
+}
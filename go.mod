@@ -27,11 +27,14 @@ require (
 	github.com/stretchr/testify v1.7.0
 	github.com/tychoish/grip v0.4.1
 	github.com/urfave/negroni v1.0.0
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	go.mongodb.org/mongo-driver v1.3.3
 	gopkg.in/ldap.v3 v3.1.0
 	gopkg.in/yaml.v2 v2.3.0
 )
 
+require github.com/tychoish/fun v0.13.0
+
 require (
 	cloud.google.com/go v0.34.0 // indirect
 	github.com/BurntSushi/toml v0.3.1 // indirect
@@ -94,7 +97,7 @@ require (
 	github.com/tadvi/systray v0.0.0-20190226123456-11a2b8fa57af // indirect
 	github.com/tidwall/pretty v1.0.0 // indirect
 	github.com/trivago/tgo v1.0.7 // indirect
-	github.com/tychoish/fun v0.13.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
 	github.com/xdg/scram v0.0.0-20180814205039-7eeb5667e42c // indirect
 	github.com/xdg/stringprep v0.0.0-20180714160509-73f8eece6fdc // indirect
 	github.com/yuin/goldmark v1.2.1 // indirect
@@ -16,6 +16,11 @@ func writeResponse(of OutputFormat, w http.ResponseWriter, code int, data interf
 
 	w.WriteHeader(code)
 
+	// responses with these statuses cannot have a body.
+	if code == http.StatusNoContent || code == http.StatusNotModified {
+		return
+	}
+
 	size, err := writePayload(w, data)
 
 	if err != nil {
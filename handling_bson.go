@@ -0,0 +1,44 @@
+package gimlet
+
+import (
+	"net/http"
+
+	"github.com/tychoish/grip"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// WriteBSONResponse writes a BSON document to the body of an HTTP
+// request, setting the return status of to 500 if the BSON
+// seralization process encounters an error, otherwise return. BSON
+// documents must be structs or maps.
+func WriteBSONResponse(w http.ResponseWriter, code int, data interface{}) {
+	out, err := bson.Marshal(data)
+	if err != nil {
+		grip.Debug(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(BSON, w, code, out)
+}
+
+// WriteBSON is a helper method to write BSON data to the body of an
+// HTTP request and return 200 (successful.)
+func WriteBSON(w http.ResponseWriter, data interface{}) {
+	// 200
+	WriteBSONResponse(w, http.StatusOK, data)
+}
+
+// WriteBSONError is a helper method to write BSON data to the body of
+// an HTTP request and return 400 (user error.)
+func WriteBSONError(w http.ResponseWriter, data interface{}) {
+	// 400
+	WriteBSONResponse(w, http.StatusBadRequest, data)
+}
+
+// WriteBSONInternalError is a helper method to write BSON data to the
+// body of an HTTP request and return 500 (internal error.)
+func WriteBSONInternalError(w http.ResponseWriter, data interface{}) {
+	// 500
+	WriteBSONResponse(w, http.StatusInternalServerError, data)
+}
@@ -0,0 +1,124 @@
+package gimlet
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type encodingTestDoc struct {
+	XMLName xml.Name `bson:"-" json:"-" yaml:"-" xml:"doc"`
+	Name    string   `bson:"name" json:"name" yaml:"name" xml:"name"`
+	Count   int      `bson:"count" json:"count" yaml:"count" xml:"count"`
+	Tags    []string `bson:"tags" json:"tags" yaml:"tags" xml:"tags>tag"`
+}
+
+func TestEncodingFormats(t *testing.T) {
+	doc := encodingTestDoc{Name: "gimlet", Count: 2, Tags: []string{"a", "b"}}
+
+	for _, tc := range []struct {
+		format OutputFormat
+		write  func(http.ResponseWriter, int, interface{})
+		read   func(io.ReadCloser, interface{}) error
+	}{
+		{format: BSON, write: WriteBSONResponse, read: GetBSON},
+		{format: MSGPACK, write: WriteMsgpackResponse, read: GetMsgpack},
+		{format: XML, write: WriteXMLResponse, read: GetXML},
+	} {
+		t.Run(tc.format.String(), func(t *testing.T) {
+			t.Run("RoundTrip", func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				tc.write(rw, http.StatusCreated, doc)
+				assert.Equal(t, http.StatusCreated, rw.Code)
+				assert.Equal(t, tc.format.ContentType(), rw.Header().Get("Content-Type"))
+
+				out := encodingTestDoc{}
+				require.NoError(t, tc.read(io.NopCloser(rw.Body), &out))
+				out.XMLName = xml.Name{}
+				assert.Equal(t, doc, out)
+			})
+			t.Run("Responder", func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				resp, err := NewBasicResponder(http.StatusOK, tc.format, doc)
+				require.NoError(t, err)
+				WriteResponse(rw, resp)
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Equal(t, tc.format.ContentType(), rw.Header().Get("Content-Type"))
+			})
+			t.Run("Error", func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				WriteResponse(rw, newResponder(ErrorResponse{StatusCode: http.StatusNotFound, Message: "missing"}, http.StatusBadRequest, tc.format))
+				assert.Equal(t, http.StatusNotFound, rw.Code)
+
+				out := ErrorResponse{}
+				require.NoError(t, tc.read(io.NopCloser(rw.Body), &out))
+				assert.Equal(t, http.StatusNotFound, out.StatusCode)
+				assert.Equal(t, "missing", out.Message)
+			})
+			t.Run("Decode", func(t *testing.T) {
+				rw := httptest.NewRecorder()
+				tc.write(rw, http.StatusOK, doc)
+
+				req := httptest.NewRequest(http.MethodPost, "/", rw.Body)
+				req.Header.Set("Content-Type", tc.format.ContentType())
+				out := encodingTestDoc{}
+				require.NoError(t, GetBody(req, &out))
+				assert.Equal(t, doc.Name, out.Name)
+
+				req = httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString("\x01not valid"))
+				req.Header.Set("Content-Type", tc.format.ContentType())
+				assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, GetBody(req, &out)))
+			})
+			t.Run("Negotiation", func(t *testing.T) {
+				req := httptest.NewRequest(http.MethodGet, "/", nil)
+				req.Header.Set("Accept", tc.format.ContentType())
+				format, err := negotiateFormat(req, JSON, []OutputFormat{JSON, tc.format})
+				require.NoError(t, err)
+				assert.Equal(t, tc.format, format)
+			})
+		})
+	}
+
+	t.Run("XMLDocument", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteXML(rw, doc)
+		assert.Equal(t, xml.Header+"<doc>\n  <name>gimlet</name>\n  <count>2</count>\n  <tags>\n    <tag>a</tag>\n    <tag>b</tag>\n  </tags>\n</doc>\n", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		WriteXML(rw, map[string]string{"unsupported": "type"})
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+	t.Run("BSONRequiresDocuments", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteBSON(rw, []string{"not", "a", "document"})
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+
+		rw = httptest.NewRecorder()
+		WriteBSON(rw, map[string]int{"n": 1})
+		out := bson.M{}
+		require.NoError(t, bson.Unmarshal(rw.Body.Bytes(), &out))
+		assert.EqualValues(t, 1, out["n"])
+	})
+	t.Run("MsgpackUsesJSONNames", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteMsgpack(rw, doc)
+		out := map[string]interface{}{}
+		require.NoError(t, msgpack.Unmarshal(rw.Body.Bytes(), &out))
+		assert.Equal(t, "gimlet", out["name"])
+		assert.NotContains(t, out, "XMLName")
+
+		req := httptest.NewRequest(http.MethodPost, "/", rw.Body)
+		req.Header.Set("Content-Type", "application/x-msgpack")
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, DecodeRequest(req, &struct {
+			Name string `json:"name"`
+		}{}, DecodeOptions{DisallowUnknownFields: true})))
+	})
+}
@@ -0,0 +1,49 @@
+package gimlet
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/tychoish/grip"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// WriteMsgpackResponse writes a MessagePack document to the body of
+// an HTTP request, setting the return status of to 500 if the
+// MessagePack seralization process encounters an error, otherwise
+// return. Struct fields use the names in their json struct tags.
+func WriteMsgpackResponse(w http.ResponseWriter, code int, data interface{}) {
+	buf := &bytes.Buffer{}
+	enc := msgpack.NewEncoder(buf)
+	enc.SetCustomStructTag("json")
+
+	if err := enc.Encode(data); err != nil {
+		grip.Debug(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(MSGPACK, w, code, buf.Bytes())
+}
+
+// WriteMsgpack is a helper method to write MessagePack data to the
+// body of an HTTP request and return 200 (successful.)
+func WriteMsgpack(w http.ResponseWriter, data interface{}) {
+	// 200
+	WriteMsgpackResponse(w, http.StatusOK, data)
+}
+
+// WriteMsgpackError is a helper method to write MessagePack data to
+// the body of an HTTP request and return 400 (user error.)
+func WriteMsgpackError(w http.ResponseWriter, data interface{}) {
+	// 400
+	WriteMsgpackResponse(w, http.StatusBadRequest, data)
+}
+
+// WriteMsgpackInternalError is a helper method to write MessagePack
+// data to the body of an HTTP request and return 500 (internal
+// error.)
+func WriteMsgpackInternalError(w http.ResponseWriter, data interface{}) {
+	// 500
+	WriteMsgpackResponse(w, http.StatusInternalServerError, data)
+}
@@ -0,0 +1,127 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"iter"
+	"net/http"
+	"reflect"
+
+	"github.com/tychoish/grip"
+)
+
+// WriteNDJSONResponse writes data to the body of an HTTP response
+// as newline-delimited JSON: slices and arrays produce one line per
+// element, and other values produce a single line.
+func WriteNDJSONResponse(w http.ResponseWriter, code int, data interface{}) {
+	buf := &bytes.Buffer{}
+	enc := json.NewEncoder(buf)
+
+	var err error
+	if val := reflect.ValueOf(data); data != nil && (val.Kind() == reflect.Slice || val.Kind() == reflect.Array) && val.Type().Elem().Kind() != reflect.Uint8 {
+		for i := 0; i < val.Len() && err == nil; i++ {
+			err = enc.Encode(val.Index(i).Interface())
+		}
+	} else {
+		err = enc.Encode(data)
+	}
+
+	if err != nil {
+		grip.Debug(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeResponse(NDJSON, w, code, buf.Bytes())
+}
+
+// WriteNDJSON is a helper method to write newline-delimited JSON data
+// to the body of an HTTP request and return 200 (successful.)
+func WriteNDJSON(w http.ResponseWriter, data interface{}) {
+	// 200
+	WriteNDJSONResponse(w, http.StatusOK, data)
+}
+
+// NewNDJSONStreamResponse constructs a StreamResponder that writes
+// each item of the sequence as a line of JSON, flushing the response
+// after every item. The responder stops at the first error in the
+// sequence, or when the request's context is canceled.
+//
+// If the sequence produces an error before its first item, the
+// responder writes an error response. Otherwise, the response has a
+// 200 status, and the responder reports errors in the X-Stream-Error
+// trailer.
+func NewNDJSONStreamResponse[T any](items iter.Seq2[T, error]) Responder {
+	var source interface{}
+	if items != nil {
+		source = items
+	}
+
+	return &ndjsonStreamResponder[T]{
+		streamResponder: streamResponder{source: source, format: NDJSON, status: http.StatusOK},
+		items:           func(context.Context) iter.Seq2[T, error] { return items },
+	}
+}
+
+// NewNDJSONChannelResponse constructs a StreamResponder, as
+// NewNDJSONStreamResponse, that writes the items that it receives
+// from the channel until the channel is closed.
+func NewNDJSONChannelResponse[T any](items <-chan T) Responder {
+	var source interface{}
+	if items != nil {
+		source = items
+	}
+
+	return &ndjsonStreamResponder[T]{
+		streamResponder: streamResponder{source: source, format: NDJSON, status: http.StatusOK},
+		items:           func(ctx context.Context) iter.Seq2[T, error] { return channelSeq(ctx, items) },
+	}
+}
+
+type ndjsonStreamResponder[T any] struct {
+	streamResponder
+	items func(context.Context) iter.Seq2[T, error]
+}
+
+func (r *ndjsonStreamResponder[T]) Stream(ctx context.Context, rw http.ResponseWriter) error {
+	started := false
+	enc := json.NewEncoder(rw)
+
+	for item, err := range r.items(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			if !started {
+				eresp := streamErrorResponse(err)
+				WriteJSONResponse(rw, eresp.StatusCode, eresp)
+				return err
+			}
+
+			reportStreamError(rw, err)
+			return err
+		}
+
+		if !started {
+			started = true
+			startStream(rw, NDJSON, r.status)
+		}
+
+		if err := enc.Encode(item); err != nil {
+			reportStreamError(rw, err)
+			return err
+		}
+
+		if err := flush(rw); err != nil {
+			return err
+		}
+	}
+
+	if !started {
+		startStream(rw, NDJSON, r.status)
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+)
+
+// Event is a server-sent event, as written by SSE responders.
+type Event struct {
+	// ID sets the client's last event ID, which clients send in
+	// the Last-Event-ID header when they reconnect.
+	ID string
+	// Name is the type of the event, which defaults to "message"
+	// on the client.
+	Name string
+	// Data is the payload of the event: strings and byte slices
+	// are written as is, and other values are written as JSON.
+	Data interface{}
+	// Retry, when set, changes the time that the client waits
+	// before reconnecting.
+	Retry time.Duration
+}
+
+func (e Event) write(w io.Writer) error {
+	buf := &bytes.Buffer{}
+
+	if e.ID != "" {
+		if strings.ContainsAny(e.ID, "\r\n\x00") {
+			return errors.Errorf("event id '%s' contains invalid characters", e.ID)
+		}
+		fmt.Fprintf(buf, "id: %s\n", e.ID)
+	}
+
+	if e.Name != "" {
+		if strings.ContainsAny(e.Name, "\r\n") {
+			return errors.Errorf("event name '%s' contains invalid characters", e.Name)
+		}
+		fmt.Fprintf(buf, "event: %s\n", e.Name)
+	}
+
+	if e.Retry > 0 {
+		fmt.Fprintf(buf, "retry: %d\n", e.Retry.Milliseconds())
+	}
+
+	var data string
+	switch payload := e.Data.(type) {
+	case nil:
+	case string:
+		data = payload
+	case []byte:
+		data = string(payload)
+	default:
+		out, err := json.Marshal(payload)
+		if err != nil {
+			return errors.Wrap(err, "problem encoding event data")
+		}
+		data = string(out)
+	}
+
+	if e.Data != nil {
+		data = strings.ReplaceAll(data, "\r\n", "\n")
+		for _, line := range strings.Split(data, "\n") {
+			fmt.Fprintf(buf, "data: %s\n", line)
+		}
+	}
+
+	buf.WriteString("\n")
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// GetLastEventID returns the ID of the last server-sent event that a
+// reconnecting client received, from the Last-Event-ID header or, for
+// clients that cannot set headers, the "lastEventId" query
+// parameter. Handlers use this ID to resume streams.
+func GetLastEventID(r *http.Request) string {
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		return id
+	}
+
+	return r.URL.Query().Get("lastEventId")
+}
+
+// WriteSSEResponse writes data to the body of an HTTP response as
+// server-sent events: Event values, and the elements of []Event,
+// are written as events, and other values are written as the data of
+// a single event.
+func WriteSSEResponse(w http.ResponseWriter, code int, data interface{}) {
+	var events []Event
+	switch d := data.(type) {
+	case Event:
+		events = []Event{d}
+	case []Event:
+		events = d
+	default:
+		events = []Event{{Data: d}}
+	}
+
+	buf := &bytes.Buffer{}
+	for _, e := range events {
+		if err := e.write(buf); err != nil {
+			grip.Debug(err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+
+	w.Header().Set("Cache-Control", "no-cache")
+	writeResponse(SSE, w, code, buf.Bytes())
+}
+
+// WriteSSE is a helper method to write server-sent events to the body
+// of an HTTP request and return 200 (successful.)
+func WriteSSE(w http.ResponseWriter, data interface{}) {
+	// 200
+	WriteSSEResponse(w, http.StatusOK, data)
+}
+
+// SSEOptions configures streaming SSE responders.
+type SSEOptions struct {
+	// Retry, when set, is the reconnection time that the responder
+	// sends to the client at the beginning of the stream.
+	Retry time.Duration
+}
+
+// NewSSEStreamResponse constructs a StreamResponder that writes the
+// events of the sequence as server-sent events, flushing the response
+// after every event. The responder stops at the first error in the
+// sequence, or when the request's context is canceled. Handlers that
+// support resuming streams should use GetLastEventID to determine
+// the first event of the sequence.
+//
+// If the sequence produces an error before its first event, the
+// responder writes an error response. Otherwise, the responder sends
+// the error to the client as an "error" event, and reports it in the
+// X-Stream-Error trailer.
+func NewSSEStreamResponse(events iter.Seq2[Event, error], opts SSEOptions) Responder {
+	var source interface{}
+	if events != nil {
+		source = events
+	}
+
+	return &sseStreamResponder{
+		streamResponder: streamResponder{source: source, format: SSE, status: http.StatusOK},
+		events:          func(context.Context) iter.Seq2[Event, error] { return events },
+		opts:            opts,
+	}
+}
+
+// NewSSEChannelResponse constructs a StreamResponder, as
+// NewSSEStreamResponse, that writes the events that it receives from
+// the channel until the channel is closed.
+func NewSSEChannelResponse(events <-chan Event, opts SSEOptions) Responder {
+	var source interface{}
+	if events != nil {
+		source = events
+	}
+
+	return &sseStreamResponder{
+		streamResponder: streamResponder{source: source, format: SSE, status: http.StatusOK},
+		events:          func(ctx context.Context) iter.Seq2[Event, error] { return channelSeq(ctx, events) },
+		opts:            opts,
+	}
+}
+
+type sseStreamResponder struct {
+	streamResponder
+	events func(context.Context) iter.Seq2[Event, error]
+	opts   SSEOptions
+}
+
+func (r *sseStreamResponder) start(rw http.ResponseWriter) error {
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("X-Accel-Buffering", "no")
+	startStream(rw, SSE, r.status)
+
+	if r.opts.Retry > 0 {
+		if _, err := fmt.Fprintf(rw, "retry: %d\n\n", r.opts.Retry.Milliseconds()); err != nil {
+			return err
+		}
+	}
+
+	return flush(rw)
+}
+
+func (r *sseStreamResponder) Stream(ctx context.Context, rw http.ResponseWriter) error {
+	started := false
+
+	for event, err := range r.events(ctx) {
+		if err == nil {
+			err = ctx.Err()
+		}
+
+		if err != nil {
+			if !started {
+				eresp := streamErrorResponse(err)
+				WriteJSONResponse(rw, eresp.StatusCode, eresp)
+				return err
+			}
+
+			// clients that disconnected cannot receive the
+			// error event.
+			if ctx.Err() == nil {
+				_ = Event{Name: "error", Data: streamErrorResponse(err)}.write(rw)
+			}
+			reportStreamError(rw, err)
+			return err
+		}
+
+		if !started {
+			started = true
+			if err := r.start(rw); err != nil {
+				return err
+			}
+		}
+
+		if err := event.write(rw); err != nil {
+			reportStreamError(rw, err)
+			return err
+		}
+
+		if err := flush(rw); err != nil {
+			return err
+		}
+	}
+
+	if !started {
+		return r.start(rw)
+	}
+
+	return nil
+}
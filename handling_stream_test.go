@@ -0,0 +1,187 @@
+package gimlet
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type streamTestItem struct {
+	ID int `json:"id"`
+}
+
+func streamTestItems(n, failAt int) iter.Seq2[streamTestItem, error] {
+	return func(yield func(streamTestItem, error) bool) {
+		for i := 0; i < n; i++ {
+			if i == failAt {
+				yield(streamTestItem{}, ErrorResponse{StatusCode: http.StatusServiceUnavailable, Message: "source failed"})
+				return
+			}
+			if !yield(streamTestItem{ID: i}, nil) {
+				return
+			}
+		}
+	}
+}
+
+// flushRecorder records the body at every flush.
+type flushRecorder struct {
+	*httptest.ResponseRecorder
+	flushes []string
+}
+
+func (r *flushRecorder) Flush() {
+	r.flushes = append(r.flushes, r.Body.String())
+	r.ResponseRecorder.Flush()
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Run("WriteResponse", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteNDJSON(rw, []streamTestItem{{ID: 1}, {ID: 2}})
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, NDJSON.ContentType(), rw.Header().Get("Content-Type"))
+		assert.Equal(t, "{\"id\":1}\n{\"id\":2}\n", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		WriteResponse(rw, &responderImpl{data: streamTestItem{ID: 3}, format: NDJSON, status: http.StatusCreated})
+		assert.Equal(t, http.StatusCreated, rw.Code)
+		assert.Equal(t, "{\"id\":3}\n", rw.Body.String())
+	})
+	t.Run("Stream", func(t *testing.T) {
+		rw := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		resp := NewNDJSONStreamResponse(streamTestItems(3, -1))
+		require.NoError(t, resp.Validate())
+		WriteResponse(rw, resp)
+
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, NDJSON.ContentType(), rw.Header().Get("Content-Type"))
+		assert.Equal(t, "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n", rw.Body.String())
+		assert.Equal(t, []string{"{\"id\":0}\n", "{\"id\":0}\n{\"id\":1}\n", "{\"id\":0}\n{\"id\":1}\n{\"id\":2}\n"}, rw.flushes)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		assert.Error(t, NewNDJSONStreamResponse(streamTestItems(3, 1)).(StreamResponder).Stream(context.Background(), rw))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "{\"id\":0}\n", rw.Body.String())
+		assert.Contains(t, rw.Result().Trailer.Get(StreamErrorTrailer), "source failed")
+
+		rw = httptest.NewRecorder()
+		assert.Error(t, NewNDJSONStreamResponse(streamTestItems(3, 0)).(StreamResponder).Stream(context.Background(), rw))
+		assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+		assert.Contains(t, rw.Body.String(), "source failed")
+	})
+	t.Run("Channel", func(t *testing.T) {
+		ch := make(chan streamTestItem)
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		rw := httptest.NewRecorder()
+		done := make(chan error)
+		go func() { done <- NewNDJSONChannelResponse(ch).(StreamResponder).Stream(ctx, rw) }()
+
+		// the stream receives each item after writing the
+		// previous item.
+		ch <- streamTestItem{ID: 1}
+		ch <- streamTestItem{ID: 2}
+		ch <- streamTestItem{ID: 3}
+		cancel()
+
+		assert.ErrorIs(t, <-done, context.Canceled)
+		assert.True(t, strings.HasPrefix(rw.Body.String(), "{\"id\":1}\n{\"id\":2}\n"))
+	})
+}
+
+func TestSSE(t *testing.T) {
+	t.Run("Events", func(t *testing.T) {
+		rw := httptest.NewRecorder()
+		WriteSSE(rw, []Event{
+			{ID: "1", Name: "update", Data: map[string]int{"id": 1}, Retry: time.Second},
+			{Data: "first\nsecond"},
+		})
+
+		assert.Equal(t, SSE.ContentType(), rw.Header().Get("Content-Type"))
+		assert.Equal(t, "no-cache", rw.Header().Get("Cache-Control"))
+		assert.Equal(t, "id: 1\nevent: update\nretry: 1000\ndata: {\"id\":1}\n\ndata: first\ndata: second\n\n", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		WriteSSE(rw, "hello")
+		assert.Equal(t, "data: hello\n\n", rw.Body.String())
+
+		rw = httptest.NewRecorder()
+		WriteSSE(rw, Event{ID: "bad\nid"})
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+	t.Run("Resume", func(t *testing.T) {
+		events := func(after int) iter.Seq2[Event, error] {
+			return func(yield func(Event, error) bool) {
+				for i := after + 1; i <= 3; i++ {
+					if !yield(Event{ID: strconv.Itoa(i), Data: i}, nil) {
+						return
+					}
+				}
+			}
+		}
+
+		handler := func(rw http.ResponseWriter, r *http.Request) {
+			after, _ := strconv.Atoi(GetLastEventID(r))
+			WriteResponse(rw, NewSSEStreamResponse(events(after), SSEOptions{Retry: 2 * time.Second}))
+		}
+
+		rw := &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "no", rw.Header().Get("X-Accel-Buffering"))
+		assert.Equal(t, "retry: 2000\n\nid: 1\ndata: 1\n\nid: 2\ndata: 2\n\nid: 3\ndata: 3\n\n", rw.Body.String())
+		assert.Len(t, rw.flushes, 4)
+
+		rw = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("Last-Event-ID", "2")
+		handler(rw, req)
+		assert.Equal(t, "retry: 2000\n\nid: 3\ndata: 3\n\n", rw.Body.String())
+
+		rw = &flushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		handler(rw, httptest.NewRequest(http.MethodGet, "/?lastEventId=3", nil))
+		assert.Equal(t, "retry: 2000\n\n", rw.Body.String())
+	})
+	t.Run("Errors", func(t *testing.T) {
+		events := func(yield func(Event, error) bool) {
+			if yield(Event{Data: "ok"}, nil) {
+				yield(Event{}, errors.New("source failed"))
+			}
+		}
+
+		rw := httptest.NewRecorder()
+		assert.Error(t, NewSSEStreamResponse(events, SSEOptions{}).(StreamResponder).Stream(context.Background(), rw))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.True(t, strings.HasPrefix(rw.Body.String(), "data: ok\n\nevent: error\ndata: {"))
+		assert.Equal(t, "source failed", rw.Result().Trailer.Get(StreamErrorTrailer))
+
+		rw = httptest.NewRecorder()
+		failing := func(yield func(Event, error) bool) { yield(Event{}, errors.New("source failed")) }
+		assert.Error(t, NewSSEStreamResponse(failing, SSEOptions{}).(StreamResponder).Stream(context.Background(), rw))
+		assert.Equal(t, http.StatusInternalServerError, rw.Code)
+	})
+	t.Run("Handler", func(t *testing.T) {
+		ch := make(chan Event, 2)
+		ch <- Event{Name: "tick", Data: 1}
+		ch <- Event{Name: "tick", Data: 2}
+		close(ch)
+
+		handler := handleHandler(respondWith(NewSSEChannelResponse(ch, SSEOptions{})))
+		rw := httptest.NewRecorder()
+		handler(rw, httptest.NewRequest(http.MethodGet, "/", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "event: tick\ndata: 1\n\nevent: tick\ndata: 2\n\n", rw.Body.String())
+	})
+}
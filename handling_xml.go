@@ -0,0 +1,44 @@
+package gimlet
+
+import (
+	"encoding/xml"
+	"net/http"
+
+	"github.com/tychoish/grip"
+)
+
+// WriteXMLResponse writes an XML document to the body of an HTTP
+// request, setting the return status of to 500 if the XML
+// seralization process encounters an error, otherwise return.
+func WriteXMLResponse(w http.ResponseWriter, code int, data interface{}) {
+	response, err := xml.MarshalIndent(data, "", "  ")
+	if err != nil {
+		grip.Debug(err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	out := append([]byte(xml.Header), response...)
+	writeResponse(XML, w, code, append(out, []byte("\n")...))
+}
+
+// WriteXML is a helper method to write XML data to the body of an
+// HTTP request and return 200 (successful.)
+func WriteXML(w http.ResponseWriter, data interface{}) {
+	// 200
+	WriteXMLResponse(w, http.StatusOK, data)
+}
+
+// WriteXMLError is a helper method to write XML data to the body of
+// an HTTP request and return 400 (user error.)
+func WriteXMLError(w http.ResponseWriter, data interface{}) {
+	// 400
+	WriteXMLResponse(w, http.StatusBadRequest, data)
+}
+
+// WriteXMLInternalError is a helper method to write XML data to the
+// body of an HTTP request and return 500 (internal error.)
+func WriteXMLInternalError(w http.ResponseWriter, data interface{}) {
+	// 500
+	WriteXMLResponse(w, http.StatusInternalServerError, data)
+}
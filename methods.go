@@ -48,12 +48,17 @@ const (
 	YAML
 	BINARY
 	CSV
+	NDJSON
+	SSE
+	BSON
+	MSGPACK
+	XML
 )
 
 // IsValid provides a predicate to validate OutputFormat values.
 func (o OutputFormat) IsValid() bool {
 	switch o {
-	case JSON, TEXT, HTML, BINARY, YAML, CSV:
+	case JSON, TEXT, HTML, BINARY, YAML, CSV, NDJSON, SSE, BSON, MSGPACK, XML:
 		return true
 	default:
 		return false
@@ -74,6 +79,16 @@ func (o OutputFormat) String() string {
 		return "yaml"
 	case CSV:
 		return "csv"
+	case NDJSON:
+		return "ndjson"
+	case SSE:
+		return "sse"
+	case BSON:
+		return "bson"
+	case MSGPACK:
+		return "msgpack"
+	case XML:
+		return "xml"
 	default:
 		return "text"
 	}
@@ -95,6 +110,16 @@ func (o OutputFormat) ContentType() string {
 		return "application/yaml; charset=utf-8"
 	case CSV:
 		return "application/csv; charset=utf-8"
+	case NDJSON:
+		return "application/x-ndjson; charset=utf-8"
+	case SSE:
+		return "text/event-stream; charset=utf-8"
+	case BSON:
+		return "application/bson"
+	case MSGPACK:
+		return "application/msgpack"
+	case XML:
+		return "application/xml; charset=utf-8"
 	default:
 		return "text/plain; charset=utf-8"
 
@@ -27,11 +27,17 @@ func TestMethodString(t *testing.T) {
 func TestOutputFormat(t *testing.T) {
 	assert := assert.New(t)
 	cases := map[OutputFormat]string{
-		JSON:   "json",
-		TEXT:   "text",
-		HTML:   "html",
-		YAML:   "yaml",
-		BINARY: "binary",
+		JSON:    "json",
+		TEXT:    "text",
+		HTML:    "html",
+		YAML:    "yaml",
+		BINARY:  "binary",
+		CSV:     "csv",
+		NDJSON:  "ndjson",
+		SSE:     "sse",
+		BSON:    "bson",
+		MSGPACK: "msgpack",
+		XML:     "xml",
 	}
 
 	for meth, out := range cases {
@@ -17,4 +17,11 @@ const (
 	userManagerKey
 	userKey
 	loggingAnnotationsKey
+	routerAdapterKey
+	urlResolverKey
+	versionKey
+	maxRequestSizeKey
+	etagModeKey
+	problemDetailsKey
+	batchKey
 )
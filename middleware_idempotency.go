@@ -0,0 +1,339 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+	"github.com/tychoish/grip/message"
+)
+
+const (
+	// IdempotencyKeyHeader is the request header that identifies
+	// retries of the same request.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader marks responses that the
+	// idempotency middleware replayed from an earlier request.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	maxIdempotencyKeyLength = 255
+	defaultIdempotencyTTL   = 24 * time.Hour
+)
+
+// IdempotencyRecord is the state of a request with an idempotency
+// key. Records are incomplete while the first request with the key
+// is in progress, and then hold the response to that request.
+type IdempotencyRecord struct {
+	// Fingerprint identifies the method, URL and body of the
+	// request.
+	Fingerprint string      `bson:"fingerprint" json:"fingerprint" yaml:"fingerprint"`
+	Complete    bool        `bson:"complete" json:"complete" yaml:"complete"`
+	Status      int         `bson:"status" json:"status" yaml:"status"`
+	Header      http.Header `bson:"header" json:"header" yaml:"header"`
+	Body        []byte      `bson:"body" json:"body" yaml:"body"`
+	Created     time.Time   `bson:"created" json:"created" yaml:"created"`
+}
+
+// IdempotencyStore persists idempotency records. Implementations must
+// expire records after a TTL, so that clients can eventually reuse
+// keys.
+type IdempotencyStore interface {
+	// Begin stores the record for the key if there is no record
+	// for the key, and returns true. Otherwise Begin returns the
+	// existing record and false. Begin must be atomic.
+	Begin(context.Context, string, IdempotencyRecord) (IdempotencyRecord, bool, error)
+	// Complete replaces the record for the key with the record of
+	// the response.
+	Complete(context.Context, string, IdempotencyRecord) error
+	// Release removes the record for the key, so that clients
+	// can retry requests that did not complete.
+	Release(context.Context, string) error
+}
+
+// NewInMemoryIdempotencyStore returns an IdempotencyStore which keeps
+// records in memory for the TTL, and removes expired records until
+// the context is canceled. The TTL must be positive.
+func NewInMemoryIdempotencyStore(ctx context.Context, ttl time.Duration) (IdempotencyStore, error) {
+	if ttl <= 0 {
+		return nil, errors.Errorf("idempotency TTL must be positive, not %s", ttl)
+	}
+
+	s := &idempotencyStore{
+		ttl:     ttl,
+		records: map[string]IdempotencyRecord{},
+	}
+
+	go func() {
+		timer := time.NewTimer(ttl / 2)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				s.clean()
+				timer.Reset(ttl / 2)
+			}
+		}
+	}()
+
+	return s, nil
+}
+
+type idempotencyStore struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	records map[string]IdempotencyRecord
+}
+
+func (s *idempotencyStore) clean() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for k, v := range s.records {
+		if time.Since(v.Created) >= s.ttl {
+			delete(s.records, k)
+		}
+	}
+}
+
+func (s *idempotencyStore) Begin(_ context.Context, key string, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.records[key]; ok && time.Since(existing.Created) < s.ttl {
+		return existing, false, nil
+	}
+
+	s.records[key] = rec
+	return rec, true, nil
+}
+
+func (s *idempotencyStore) Complete(_ context.Context, key string, rec IdempotencyRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.records[key]; !ok {
+		return errors.Errorf("no request in progress for idempotency key '%s'", key)
+	}
+
+	s.records[key] = rec
+	return nil
+}
+
+func (s *idempotencyStore) Release(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
+
+// IdempotencyOptions configures the idempotency middleware.
+type IdempotencyOptions struct {
+	// Store persists the records of requests. The default store
+	// keeps records in memory for 24 hours, until the context
+	// passed to NewIdempotencyHandler is canceled.
+	Store IdempotencyStore
+	// Methods are the methods of the requests that the middleware
+	// deduplicates, and default to POST and PATCH.
+	Methods []string
+}
+
+// NewIdempotencyHandler produces middleware that makes retries of
+// requests with unsafe methods safe. Clients identify retries of a
+// request with the same value of the Idempotency-Key header: the
+// middleware records the status, headers and body of the response to
+// the first request with a key, and replays that response, with the
+// Idempotent-Replayed header, for later requests with the key.
+//
+// Keys are scoped to the user attached to the request (see GetUser),
+// and the middleware does not deduplicate the requests of anonymous
+// users. Requests that reuse a key while the first request is in
+// progress, or with a different method, URL or body than the first
+// request, receive a 409 (conflict) response. Responses with 5xx
+// statuses are not recorded, so that clients can retry them.
+//
+// Canceling the context stops the default store from removing
+// expired records.
+func NewIdempotencyHandler(ctx context.Context, opts IdempotencyOptions) Middleware {
+	if opts.Store == nil {
+		opts.Store, _ = NewInMemoryIdempotencyStore(ctx, defaultIdempotencyTTL)
+	}
+
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{http.MethodPost, http.MethodPatch}
+	}
+
+	return &idempotencyHandler{opts: opts}
+}
+
+type idempotencyHandler struct {
+	opts IdempotencyOptions
+}
+
+func (h *idempotencyHandler) handles(method string) bool {
+	for _, m := range h.opts.Methods {
+		if m == method {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (h *idempotencyHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request, next http.HandlerFunc) {
+	ctx := r.Context()
+
+	key := r.Header.Get(IdempotencyKeyHeader)
+	if key == "" || !h.handles(r.Method) {
+		next(rw, r)
+		return
+	}
+
+	user := GetUser(ctx)
+	if user == nil {
+		next(rw, r)
+		return
+	}
+
+	if len(key) > maxIdempotencyKeyLength {
+		WriteResponse(rw, MakeJSONErrorResponder(ErrorResponse{
+			StatusCode: http.StatusBadRequest,
+			Message:    fmt.Sprintf("idempotency key must not be longer than %d characters", maxIdempotencyKeyLength),
+		}))
+		return
+	}
+
+	fingerprint, err := fingerprintRequest(r)
+	if err != nil {
+		WriteResponse(rw, MakeJSONErrorResponder(bodyError(err, "problem reading request body")))
+		return
+	}
+
+	// scope keys to the user, so that users cannot see the
+	// responses to each other's requests.
+	scoped := fmt.Sprintf("%s\x00%s", user.Username(), key)
+
+	rec, created, err := h.opts.Store.Begin(ctx, scoped, IdempotencyRecord{Fingerprint: fingerprint, Created: time.Now()})
+	if err != nil {
+		WriteResponse(rw, MakeJSONInternalErrorResponder(errors.Wrap(err, "problem recording idempotency key")))
+		return
+	}
+
+	if !created {
+		h.replay(rw, rec, fingerprint)
+		return
+	}
+
+	recorder := &idempotencyRecorder{ResponseWriter: rw}
+	completed := false
+	defer func() {
+		if !completed {
+			grip.Warning(message.WrapError(h.opts.Store.Release(context.WithoutCancel(ctx), scoped), message.Fields{
+				"message": "problem releasing idempotency key",
+				"request": GetRequestID(ctx),
+			}))
+		}
+	}()
+
+	next(recorder, r)
+
+	if recorder.status == 0 || recorder.status >= http.StatusInternalServerError {
+		return
+	}
+
+	rec.Complete = true
+	rec.Status = recorder.status
+	rec.Header = recorder.header
+	rec.Body = recorder.body.Bytes()
+	if err := h.opts.Store.Complete(context.WithoutCancel(ctx), scoped, rec); err != nil {
+		grip.Warning(message.WrapError(err, message.Fields{
+			"message": "problem recording response for idempotency key",
+			"request": GetRequestID(ctx),
+		}))
+		return
+	}
+
+	completed = true
+}
+
+func (h *idempotencyHandler) replay(rw http.ResponseWriter, rec IdempotencyRecord, fingerprint string) {
+	switch {
+	case rec.Fingerprint != fingerprint:
+		WriteResponse(rw, MakeJSONErrorResponder(ErrorResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "idempotency key was used for a different request",
+		}))
+	case !rec.Complete:
+		rw.Header().Set("Retry-After", "1")
+		WriteResponse(rw, MakeJSONErrorResponder(ErrorResponse{
+			StatusCode: http.StatusConflict,
+			Message:    "a request with this idempotency key is in progress",
+		}))
+	default:
+		for k, v := range rec.Header {
+			rw.Header()[k] = append([]string(nil), v...)
+		}
+		rw.Header().Set(IdempotentReplayedHeader, "true")
+		rw.WriteHeader(rec.Status)
+
+		if _, err := rw.Write(rec.Body); err != nil {
+			grip.Warning(errors.Wrap(err, "problem replaying response"))
+		}
+	}
+}
+
+// fingerprintRequest hashes the method, URL and body of the request,
+// and restores the body so that handlers can read it.
+func fingerprintRequest(r *http.Request) (string, error) {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", r.Method, r.URL.RequestURI())
+
+	if r.Body != nil && r.Body != http.NoBody {
+		body, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, getMaxRequestSize(r.Context())))
+		if err != nil {
+			return "", err
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		_, _ = hash.Write(body)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// idempotencyRecorder records a response as the handler writes it.
+type idempotencyRecorder struct {
+	http.ResponseWriter
+	status int
+	header http.Header
+	body   bytes.Buffer
+}
+
+func (r *idempotencyRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *idempotencyRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	r.body.Write(p)
+	return r.ResponseWriter.Write(p)
+}
+
+func (r *idempotencyRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
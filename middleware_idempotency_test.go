@@ -0,0 +1,165 @@
+package gimlet
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	user := &MockUser{ID: "alice"}
+	var calls atomic.Int64
+	release := make(chan struct{})
+	started := make(chan struct{}, 1)
+
+	store, err := NewInMemoryIdempotencyStore(ctx, time.Minute)
+	require.NoError(t, err)
+	handler := NewIdempotencyHandler(ctx, IdempotencyOptions{Store: store})
+	next := func(rw http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		n := calls.Add(1)
+		switch string(body) {
+		case "slow":
+			started <- struct{}{}
+			<-release
+		case "fail":
+			rw.WriteHeader(http.StatusServiceUnavailable)
+			return
+		case "panic":
+			panic("boom")
+		}
+
+		rw.Header().Set("Location", fmt.Sprintf("/items/%d", n))
+		rw.WriteHeader(http.StatusCreated)
+		_, _ = fmt.Fprintf(rw, "created %s %d", body, n)
+	}
+
+	do := func(u User, method, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/items", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(IdempotencyKeyHeader, key)
+		}
+		if u != nil {
+			req = req.WithContext(AttachUser(req.Context(), u))
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req, next)
+		return rw
+	}
+
+	t.Run("Replay", func(t *testing.T) {
+		first := do(user, http.MethodPost, "one", "a")
+		require.Equal(t, http.StatusCreated, first.Code)
+		assert.Empty(t, first.Header().Get(IdempotentReplayedHeader))
+
+		second := do(user, http.MethodPost, "one", "a")
+		assert.Equal(t, http.StatusCreated, second.Code)
+		assert.Equal(t, first.Body.String(), second.Body.String())
+		assert.Equal(t, first.Header().Get("Location"), second.Header().Get("Location"))
+		assert.Equal(t, "true", second.Header().Get(IdempotentReplayedHeader))
+		assert.EqualValues(t, 1, calls.Load())
+	})
+	t.Run("Mismatch", func(t *testing.T) {
+		rw := do(user, http.MethodPost, "one", "b")
+		assert.Equal(t, http.StatusConflict, rw.Code)
+		assert.Contains(t, rw.Body.String(), "different request")
+	})
+	t.Run("ScopedToUser", func(t *testing.T) {
+		before := calls.Load()
+		rw := do(&MockUser{ID: "bob"}, http.MethodPost, "one", "a")
+		assert.Equal(t, http.StatusCreated, rw.Code)
+		assert.Empty(t, rw.Header().Get(IdempotentReplayedHeader))
+		assert.Equal(t, before+1, calls.Load())
+	})
+	t.Run("Passthrough", func(t *testing.T) {
+		before := calls.Load()
+		for _, rw := range []*httptest.ResponseRecorder{
+			do(nil, http.MethodPost, "one", "a"),
+			do(user, http.MethodPost, "", "a"),
+			do(user, http.MethodPut, "one", "a"),
+		} {
+			assert.Equal(t, http.StatusCreated, rw.Code)
+			assert.Empty(t, rw.Header().Get(IdempotentReplayedHeader))
+		}
+		assert.Equal(t, before+3, calls.Load())
+
+		rw := do(user, http.MethodPost, strings.Repeat("k", 256), "a")
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+	t.Run("InFlight", func(t *testing.T) {
+		done := make(chan *httptest.ResponseRecorder)
+		go func() { done <- do(user, http.MethodPost, "two", "slow") }()
+		<-started
+
+		rw := do(user, http.MethodPost, "two", "slow")
+		assert.Equal(t, http.StatusConflict, rw.Code)
+		assert.Equal(t, "1", rw.Header().Get("Retry-After"))
+
+		close(release)
+		assert.Equal(t, http.StatusCreated, (<-done).Code)
+
+		rw = do(user, http.MethodPost, "two", "slow")
+		assert.Equal(t, http.StatusCreated, rw.Code)
+		assert.Equal(t, "true", rw.Header().Get(IdempotentReplayedHeader))
+	})
+	t.Run("Failures", func(t *testing.T) {
+		before := calls.Load()
+		assert.Equal(t, http.StatusServiceUnavailable, do(user, http.MethodPost, "three", "fail").Code)
+		assert.Equal(t, http.StatusServiceUnavailable, do(user, http.MethodPost, "three", "fail").Code)
+		assert.Equal(t, before+2, calls.Load())
+
+		assert.Panics(t, func() { do(user, http.MethodPost, "four", "panic") })
+		assert.Panics(t, func() { do(user, http.MethodPost, "four", "panic") })
+	})
+	t.Run("Store", func(t *testing.T) {
+		for _, ttl := range []time.Duration{0, -time.Second} {
+			s, err := NewInMemoryIdempotencyStore(ctx, ttl)
+			assert.Error(t, err)
+			assert.Nil(t, s)
+		}
+
+		assert.NotNil(t, NewIdempotencyHandler(ctx, IdempotencyOptions{}).(*idempotencyHandler).opts.Store)
+
+		s, err := NewInMemoryIdempotencyStore(ctx, time.Minute)
+		require.NoError(t, err)
+		store := s.(*idempotencyStore)
+		assert.Error(t, store.Complete(ctx, "a", IdempotencyRecord{}))
+
+		rec, ok, err := store.Begin(ctx, "a", IdempotencyRecord{Fingerprint: "x", Created: time.Now()})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		assert.Equal(t, "x", rec.Fingerprint)
+
+		rec, ok, err = store.Begin(ctx, "a", IdempotencyRecord{Fingerprint: "y", Created: time.Now()})
+		require.NoError(t, err)
+		assert.False(t, ok)
+		assert.Equal(t, "x", rec.Fingerprint)
+
+		_, ok, err = store.Begin(ctx, "b", IdempotencyRecord{Created: time.Now().Add(-time.Hour)})
+		require.NoError(t, err)
+		assert.True(t, ok)
+		_, ok, err = store.Begin(ctx, "b", IdempotencyRecord{Created: time.Now().Add(-time.Hour)})
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		store.clean()
+		assert.Len(t, store.records, 1)
+
+		require.NoError(t, store.Release(ctx, "a"))
+		assert.Empty(t, store.records)
+	})
+}
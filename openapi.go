@@ -0,0 +1,367 @@
+package gimlet
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/fun/erc"
+	"github.com/tychoish/grip"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// routeDocumentation holds the operation metadata that routes
+// contribute to generated OpenAPI documents.
+type routeDocumentation struct {
+	operationID string
+	summary     string
+	description string
+	tags        []string
+	request     reflect.Type
+	responses   map[int]reflect.Type
+}
+
+// OperationID sets the OpenAPI operation id for the route, which
+// must be unique within the document.
+func (r *APIRoute) OperationID(id string) *APIRoute { r.doc.operationID = id; return r }
+
+// Summary sets the OpenAPI summary for the route.
+func (r *APIRoute) Summary(s string) *APIRoute { r.doc.summary = s; return r }
+
+// Description sets the OpenAPI description for the route.
+func (r *APIRoute) Description(d string) *APIRoute { r.doc.description = d; return r }
+
+// Tags adds OpenAPI tags to the route.
+func (r *APIRoute) Tags(tags ...string) *APIRoute {
+	r.doc.tags = append(r.doc.tags, tags...)
+	return r
+}
+
+// RequestType documents the type of the request body for the
+// route. Pass either a value of the type (e.g. "Widget{}") or a
+// reflect.Type.
+func (r *APIRoute) RequestType(v interface{}) *APIRoute {
+	r.doc.request = typeOf(v)
+	return r
+}
+
+// ResponseType documents the type of the response body for the
+// route for a status code. Pass either a value of the type, a
+// reflect.Type, or nil for responses without a body.
+func (r *APIRoute) ResponseType(code int, v interface{}) *APIRoute {
+	if http.StatusText(code) == "" {
+		grip.Warningf("%d is not a valid status code for route %s", code, r.route)
+		return r
+	}
+
+	if r.doc.responses == nil {
+		r.doc.responses = map[int]reflect.Type{}
+	}
+
+	r.doc.responses[code] = typeOf(v)
+	return r
+}
+
+func typeOf(v interface{}) reflect.Type {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case reflect.Type:
+		return t
+	default:
+		return reflect.TypeOf(v)
+	}
+}
+
+// OpenAPIDocument is an OpenAPI 3 document, produced from the
+// routes registered with one or more applications.
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi" yaml:"openapi"`
+	Info       OpenAPIInfo                             `json:"info" yaml:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths" yaml:"paths"`
+	Components OpenAPIComponents                       `json:"components,omitempty" yaml:"components,omitempty"`
+}
+
+// OpenAPIInfo holds the metadata about the API as a whole.
+type OpenAPIInfo struct {
+	Title       string `json:"title" yaml:"title"`
+	Version     string `json:"version" yaml:"version"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+}
+
+// OpenAPIOperation describes a single method on a path.
+type OpenAPIOperation struct {
+	OperationID string                     `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                     `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                     `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string                   `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Deprecated  bool                       `json:"deprecated,omitempty" yaml:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter         `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody        `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]OpenAPIResponse `json:"responses" yaml:"responses"`
+}
+
+// OpenAPIParameter describes a path, query, or header parameter.
+type OpenAPIParameter struct {
+	Name     string         `json:"name" yaml:"name"`
+	In       string         `json:"in" yaml:"in"`
+	Required bool           `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema   *OpenAPISchema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// OpenAPIRequestBody describes the body of a request.
+type OpenAPIRequestBody struct {
+	Required bool                        `json:"required,omitempty" yaml:"required,omitempty"`
+	Content  map[string]OpenAPIMediaType `json:"content" yaml:"content"`
+}
+
+// OpenAPIResponse describes a response for a status code.
+type OpenAPIResponse struct {
+	Description string                      `json:"description" yaml:"description"`
+	Content     map[string]OpenAPIMediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+// OpenAPIMediaType associates a schema with a content type.
+type OpenAPIMediaType struct {
+	Schema *OpenAPISchema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+// OpenAPIComponents holds the reusable schemas referenced by the
+// document's operations.
+type OpenAPIComponents struct {
+	Schemas map[string]*OpenAPISchema `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+}
+
+// OpenAPISchema is the subset of the OpenAPI schema object that
+// gimlet generates from Go types.
+type OpenAPISchema struct {
+	Ref                  string                    `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string                    `json:"format,omitempty" yaml:"format,omitempty"`
+	Pattern              string                    `json:"pattern,omitempty" yaml:"pattern,omitempty"`
+	Items                *OpenAPISchema            `json:"items,omitempty" yaml:"items,omitempty"`
+	Properties           map[string]*OpenAPISchema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	AdditionalProperties *OpenAPISchema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Required             []string                  `json:"required,omitempty" yaml:"required,omitempty"`
+}
+
+// JSON renders the document as JSON.
+func (d *OpenAPIDocument) JSON() ([]byte, error) {
+	out, err := json.MarshalIndent(d, "", "  ")
+	return out, errors.WithStack(err)
+}
+
+// YAML renders the document as YAML.
+func (d *OpenAPIDocument) YAML() (out []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = errors.Errorf("problem rendering yaml: %v", p)
+		}
+	}()
+
+	out, err = yaml.Marshal(d)
+	return out, errors.WithStack(err)
+}
+
+// OpenAPI produces an OpenAPI 3 document describing the routes that
+// the application's Handler serves, including routes from merged
+// applications.
+func (a *APIApp) OpenAPI(info OpenAPIInfo) (*OpenAPIDocument, error) {
+	gen := newOpenAPIGenerator(info)
+
+	for _, route := range a.routes {
+		for _, rv := range route.expand() {
+			gen.addRoute(a, rv, true, "")
+		}
+	}
+
+	return gen.resolve()
+}
+
+// BuildOpenAPI produces an OpenAPI 3 document describing the handler
+// that MergeApplications produces for the applications.
+func BuildOpenAPI(info OpenAPIInfo, apps ...*APIApp) (*OpenAPIDocument, error) {
+	gen := newOpenAPIGenerator(info)
+
+	for _, app := range apps {
+		for _, route := range app.routes {
+			for _, rv := range route.expand() {
+				if app.prefix != "" {
+					gen.addRoute(app, rv, false, app.prefix)
+				} else {
+					gen.addRoute(app, rv, true, "")
+				}
+			}
+		}
+	}
+
+	return gen.resolve()
+}
+
+// AddOpenAPIRoute registers a GET route that serves the OpenAPI
+// document for the application. The document is rendered as JSON,
+// or as YAML when the request specifies "?format=yaml". The document
+// is generated for every request, so it always reflects the current
+// set of routes.
+func (a *APIApp) AddOpenAPIRoute(route string, info OpenAPIInfo) *APIRoute {
+	return a.AddRoute(route).Get().Summary("OpenAPI document").Handler(func(rw http.ResponseWriter, r *http.Request) {
+		doc, err := a.OpenAPI(info)
+		if err != nil {
+			WriteResponse(rw, MakeJSONInternalErrorResponder(err))
+			return
+		}
+
+		if r.URL.Query().Get("format") == YAML.String() {
+			out, err := doc.YAML()
+			if err != nil {
+				WriteResponse(rw, MakeJSONInternalErrorResponder(err))
+				return
+			}
+			writeResponse(YAML, rw, http.StatusOK, out)
+			return
+		}
+
+		WriteJSON(rw, doc)
+	})
+}
+
+type openAPIGenerator struct {
+	doc      *OpenAPIDocument
+	schemas  map[reflect.Type]string
+	versions map[string]int
+	catcher  *erc.Collector
+}
+
+func newOpenAPIGenerator(info OpenAPIInfo) *openAPIGenerator {
+	return &openAPIGenerator{
+		doc: &OpenAPIDocument{
+			OpenAPI: "3.0.3",
+			Info:    info,
+			Paths:   map[string]map[string]*OpenAPIOperation{},
+		},
+		schemas:  map[reflect.Type]string{},
+		versions: map[string]int{},
+		catcher:  &erc.Collector{},
+	}
+}
+
+func (g *openAPIGenerator) resolve() (*OpenAPIDocument, error) {
+	if !g.catcher.Ok() {
+		return nil, g.catcher.Resolve()
+	}
+
+	return g.doc, nil
+}
+
+func (g *openAPIGenerator) addRoute(app *APIApp, route *APIRoute, addAppPrefix bool, mount string) {
+	if !route.IsValid() {
+		g.catcher.Push(fmt.Errorf("%s is not a valid route, skipping", route))
+		return
+	}
+
+	path, err := route.resolvePath(app, addAppPrefix)
+	if err != nil {
+		g.catcher.Push(err)
+		return
+	}
+
+	pattern, constraints, err := convertStdlibPattern(mount + path)
+	if err != nil {
+		g.catcher.Push(err)
+		return
+	}
+
+	var params []OpenAPIParameter
+	pattern = strings.ReplaceAll(pattern, "{$}", "")
+	for _, segment := range strings.Split(pattern, "/") {
+		if !strings.HasPrefix(segment, "{") || !strings.HasSuffix(segment, "}") {
+			continue
+		}
+
+		name := strings.TrimSuffix(strings.Trim(segment, "{}"), "...")
+		param := OpenAPIParameter{Name: name, In: "path", Required: true, Schema: &OpenAPISchema{Type: "string"}}
+		if re, ok := constraints[name]; ok {
+			param.Schema.Pattern = re.String()
+		}
+		params = append(params, param)
+	}
+	pattern = strings.ReplaceAll(pattern, "...}", "}")
+
+	if _, ok := g.doc.Paths[pattern]; !ok {
+		g.doc.Paths[pattern] = map[string]*OpenAPIOperation{}
+	}
+
+	for _, m := range route.methods {
+		method := strings.ToLower(m.String())
+		key := fmt.Sprint(method, " ", pattern)
+		if version, ok := g.versions[key]; ok {
+			// applications that only negotiate versions serve
+			// every version from the same path, and document
+			// the highest version.
+			if app.versioning.byPath() || route.version < 0 || route.version == version {
+				g.catcher.Push(errors.Errorf("'%s %s' is defined more than once", m, pattern))
+				continue
+			}
+			if route.version < version {
+				continue
+			}
+		}
+
+		g.versions[key] = route.version
+		g.doc.Paths[pattern][method] = g.operation(route, params)
+	}
+}
+
+func (g *openAPIGenerator) operation(route *APIRoute, params []OpenAPIParameter) *OpenAPIOperation {
+	op := &OpenAPIOperation{
+		OperationID: route.doc.operationID,
+		Summary:     route.doc.summary,
+		Description: route.doc.description,
+		Tags:        route.doc.tags,
+		Deprecated:  route.isDeprecated(route.version),
+		Parameters:  params,
+		Responses:   map[string]OpenAPIResponse{},
+	}
+
+	if route.doc.request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content: map[string]OpenAPIMediaType{
+				mediaType(JSON): {Schema: g.schema(route.doc.request)},
+			},
+		}
+	}
+
+	codes := make([]int, 0, len(route.doc.responses))
+	for code := range route.doc.responses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		resp := OpenAPIResponse{Description: http.StatusText(code)}
+		if t := route.doc.responses[code]; t != nil {
+			resp.Content = map[string]OpenAPIMediaType{
+				mediaType(JSON): {Schema: g.schema(t)},
+			}
+		}
+		op.Responses[fmt.Sprint(code)] = resp
+	}
+
+	if len(op.Responses) == 0 {
+		op.Responses["default"] = OpenAPIResponse{Description: "undocumented response"}
+	}
+
+	return op
+}
+
+// mediaType returns the content type for the output format without
+// any parameters (e.g. charset).
+func mediaType(of OutputFormat) string {
+	ct, _, _ := strings.Cut(of.ContentType(), ";")
+	return ct
+}
@@ -0,0 +1,131 @@
+package gimlet
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	byteSliceType     = reflect.TypeOf([]byte{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// schema produces an OpenAPI schema for the type, registering named
+// struct types as components and returning references to them.
+func (g *openAPIGenerator) schema(t reflect.Type) *OpenAPISchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &OpenAPISchema{Type: "string", Format: "date-time"}
+	case t == byteSliceType:
+		return &OpenAPISchema{Type: "string", Format: "byte"}
+	case t.Kind() != reflect.String && t.Implements(textMarshalerType):
+		return &OpenAPISchema{Type: "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &OpenAPISchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &OpenAPISchema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &OpenAPISchema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &OpenAPISchema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &OpenAPISchema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &OpenAPISchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		return &OpenAPISchema{Type: "array", Items: g.schema(t.Elem())}
+	case reflect.Map:
+		return &OpenAPISchema{Type: "object", AdditionalProperties: g.schema(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return g.structSchema(t)
+		}
+
+		return &OpenAPISchema{Ref: "#/components/schemas/" + g.component(t)}
+	default:
+		// interfaces, and types which have no JSON
+		// representation, are documented as any value.
+		return &OpenAPISchema{}
+	}
+}
+
+// component registers the named type in the document's components,
+// and returns its name. The name is registered before the schema is
+// built so that recursive types resolve to references.
+func (g *openAPIGenerator) component(t reflect.Type) string {
+	if name, ok := g.schemas[t]; ok {
+		return name
+	}
+
+	if g.doc.Components.Schemas == nil {
+		g.doc.Components.Schemas = map[string]*OpenAPISchema{}
+	}
+
+	name := t.Name()
+	if _, ok := g.doc.Components.Schemas[name]; ok {
+		name = strings.ReplaceAll(fmt.Sprintf("%s.%s", t.PkgPath(), t.Name()), "/", ".")
+	}
+
+	// reserve the name while building the schema
+	g.schemas[t] = name
+	g.doc.Components.Schemas[name] = nil
+	g.doc.Components.Schemas[name] = g.structSchema(t)
+
+	return name
+}
+
+func (g *openAPIGenerator) structSchema(t reflect.Type) *OpenAPISchema {
+	out := &OpenAPISchema{Type: "object", Properties: map[string]*OpenAPISchema{}}
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, opts, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" && opts == "" {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			ft := field.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+
+			if ft.Kind() == reflect.Struct {
+				embedded := g.structSchema(ft)
+				for k, v := range embedded.Properties {
+					out.Properties[k] = v
+				}
+				out.Required = append(out.Required, embedded.Required...)
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		out.Properties[name] = g.schema(field.Type)
+
+		if field.Type.Kind() != reflect.Ptr && !strings.Contains(opts, "omitempty") {
+			out.Required = append(out.Required, name)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,202 @@
+package gimlet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+type openAPITestBase struct {
+	ID string `json:"id"`
+}
+
+type openAPITestWidget struct {
+	openAPITestBase
+	Name     string                   `json:"name"`
+	Count    int64                    `json:"count,omitempty"`
+	Created  time.Time                `json:"created"`
+	Tags     []string                 `json:"tags"`
+	Attrs    map[string]float64       `json:"attrs"`
+	Parent   *openAPITestWidget       `json:"parent"`
+	Children []openAPITestWidget      `json:"children"`
+	Extra    interface{}              `json:"extra"`
+	Ignored  string                   `json:"-"`
+	Inline   struct{ Flag bool }      `json:"inline"`
+	Lookup   map[string]ErrorResponse `json:"lookup,omitempty"`
+	private  string
+}
+
+func TestOpenAPISchemaGeneration(t *testing.T) {
+	gen := newOpenAPIGenerator(OpenAPIInfo{})
+
+	schema := gen.schema(reflect.TypeOf(&openAPITestWidget{}))
+	assert.Equal(t, "#/components/schemas/openAPITestWidget", schema.Ref)
+
+	widget := gen.doc.Components.Schemas["openAPITestWidget"]
+	require.NotNil(t, widget)
+	assert.Equal(t, "object", widget.Type)
+	assert.Equal(t, "string", widget.Properties["id"].Type)
+	assert.Equal(t, "string", widget.Properties["name"].Type)
+	assert.Equal(t, "int64", widget.Properties["count"].Format)
+	assert.Equal(t, "date-time", widget.Properties["created"].Format)
+	assert.Equal(t, "array", widget.Properties["tags"].Type)
+	assert.Equal(t, "string", widget.Properties["tags"].Items.Type)
+	assert.Equal(t, "number", widget.Properties["attrs"].AdditionalProperties.Type)
+	assert.Equal(t, schema.Ref, widget.Properties["parent"].Ref)
+	assert.Equal(t, schema.Ref, widget.Properties["children"].Items.Ref)
+	assert.Equal(t, &OpenAPISchema{}, widget.Properties["extra"])
+	assert.Equal(t, "boolean", widget.Properties["inline"].Properties["Flag"].Type)
+	assert.NotContains(t, widget.Properties, "Ignored")
+	assert.NotContains(t, widget.Properties, "private")
+	assert.NotContains(t, widget.Properties, "openAPITestBase")
+
+	assert.Contains(t, widget.Required, "id")
+	assert.Contains(t, widget.Required, "name")
+	assert.NotContains(t, widget.Required, "count")
+	assert.NotContains(t, widget.Required, "parent")
+
+	assert.Contains(t, gen.doc.Components.Schemas, "ErrorResponse")
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	noop := func(http.ResponseWriter, *http.Request) {}
+	info := OpenAPIInfo{Title: "test", Version: "1.0"}
+
+	t.Run("Application", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("api")
+		app.AddRoute("/widgets/{id:[0-9]+}").Version(2).Get().Handler(noop).
+			OperationID("getWidget").
+			Summary("get a widget").
+			Description("returns a single widget").
+			Tags("widgets").
+			ResponseType(http.StatusOK, openAPITestWidget{}).
+			ResponseType(http.StatusNotFound, ErrorResponse{}).
+			ResponseType(999, nil)
+		app.AddRoute("/widgets").Version(2).Post().Put().Handler(noop).
+			RequestType(reflect.TypeOf(openAPITestWidget{})).
+			ResponseType(http.StatusNoContent, nil)
+
+		doc, err := app.OpenAPI(info)
+		require.NoError(t, err)
+		assert.Equal(t, "3.0.3", doc.OpenAPI)
+		assert.Equal(t, info, doc.Info)
+		require.Len(t, doc.Paths, 2)
+
+		get := doc.Paths["/api/v2/widgets/{id}"]["get"]
+		require.NotNil(t, get)
+		assert.Equal(t, "getWidget", get.OperationID)
+		assert.Equal(t, "get a widget", get.Summary)
+		assert.Equal(t, "returns a single widget", get.Description)
+		assert.Equal(t, []string{"widgets"}, get.Tags)
+		require.Len(t, get.Parameters, 1)
+		assert.Equal(t, "id", get.Parameters[0].Name)
+		assert.Equal(t, "path", get.Parameters[0].In)
+		assert.True(t, get.Parameters[0].Required)
+		assert.Equal(t, "^(?:[0-9]+)$", get.Parameters[0].Schema.Pattern)
+		require.Len(t, get.Responses, 2)
+		assert.Equal(t, "#/components/schemas/openAPITestWidget", get.Responses["200"].Content["application/json"].Schema.Ref)
+		assert.Equal(t, "Not Found", get.Responses["404"].Description)
+
+		for _, method := range []string{"post", "put"} {
+			op := doc.Paths["/api/v2/widgets"][method]
+			require.NotNil(t, op, method)
+			require.NotNil(t, op.RequestBody)
+			assert.Equal(t, "#/components/schemas/openAPITestWidget", op.RequestBody.Content["application/json"].Schema.Ref)
+			assert.Nil(t, op.Responses["204"].Content)
+		}
+
+		out, err := doc.JSON()
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"$ref": "#/components/schemas/openAPITestWidget"`)
+
+		out, err = doc.YAML()
+		require.NoError(t, err)
+		roundTrip := map[string]interface{}{}
+		require.NoError(t, yaml.Unmarshal(out, &roundTrip))
+		assert.Equal(t, "3.0.3", roundTrip["openapi"])
+	})
+	t.Run("InvalidRoutes", func(t *testing.T) {
+		app := NewApp()
+		app.AddRoute("/foo").Get().Handler(noop)
+		_, err := app.OpenAPI(info)
+		assert.Error(t, err)
+
+		app = NewApp()
+		app.AddRoute("/foo").Version(1)
+		_, err = app.OpenAPI(info)
+		assert.Error(t, err)
+
+		app = NewApp()
+		app.AddRoute("/foo").Version(1).Get().Handler(noop)
+		app.AddRoute("/foo").Version(1).Get().Handler(noop)
+		_, err = app.OpenAPI(info)
+		assert.Error(t, err)
+	})
+	t.Run("DefaultResponse", func(t *testing.T) {
+		app := NewApp()
+		app.NoVersions = true
+		app.AddPrefixRoute("/files/{path...}").Get().Handler(noop)
+
+		doc, err := app.OpenAPI(info)
+		require.NoError(t, err)
+
+		op := doc.Paths["/files/{path}"]["get"]
+		require.NotNil(t, op)
+		assert.Contains(t, op.Responses, "default")
+		require.Len(t, op.Parameters, 1)
+		assert.Equal(t, "path", op.Parameters[0].Name)
+	})
+	t.Run("Merged", func(t *testing.T) {
+		app := NewApp()
+		app.SetPrefix("one")
+		app.AddRoute("/foo").Version(1).Get().Handler(noop).Summary("one")
+
+		appTwo := NewApp()
+		appTwo.AddRoute("/bar").Version(1).Get().Handler(noop).Summary("two")
+
+		doc, err := BuildOpenAPI(info, app, appTwo)
+		require.NoError(t, err)
+		assert.Equal(t, "one", doc.Paths["/one/v1/foo"]["get"].Summary)
+		assert.Equal(t, "two", doc.Paths["/v1/bar"]["get"].Summary)
+
+		root := NewApp()
+		require.NoError(t, root.Merge(app, appTwo))
+		doc, err = root.OpenAPI(info)
+		require.NoError(t, err)
+		// merged routes place the version before the prefix
+		assert.Equal(t, "one", doc.Paths["/v1/one/foo"]["get"].Summary)
+		assert.Equal(t, "two", doc.Paths["/v1/bar"]["get"].Summary)
+	})
+	t.Run("Route", func(t *testing.T) {
+		app := NewApp()
+		app.AddRoute("/foo").Version(1).Get().Handler(noop)
+		app.AddOpenAPIRoute("/openapi", info).Version(1)
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/openapi", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		doc := &OpenAPIDocument{}
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), doc))
+		assert.Contains(t, doc.Paths, "/v1/foo")
+		assert.Contains(t, doc.Paths, "/v1/openapi")
+
+		rw = httptest.NewRecorder()
+		h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/openapi?format=yaml", nil))
+		assert.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, YAML.ContentType(), rw.Header().Get("Content-Type"))
+		doc = &OpenAPIDocument{}
+		require.NoError(t, yaml.Unmarshal(rw.Body.Bytes(), doc))
+		assert.Contains(t, doc.Paths, "/v1/foo")
+	})
+}
@@ -2,6 +2,7 @@ package gimlet
 
 import (
 	"encoding/json"
+	"encoding/xml"
 	"io"
 	"io/ioutil"
 	"net/http"
@@ -9,6 +10,8 @@ import (
 	"github.com/go-chi/chi"
 	"github.com/gorilla/mux"
 	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -25,13 +28,22 @@ func GetVars(r *http.Request) map[string]string {
 }
 
 // GetParam provides a common interface for getting a URL parameter
-// that uses gorilla/mux or chi.
+// that uses gorilla/mux, chi, the standard library http.ServeMux, or
+// the RouterAdapter that dispatched the request.
 func GetParam(r *http.Request, k string) string {
+	if router := getRouterAdapter(r.Context()); router != nil {
+		return router.Param(r, k)
+	}
+
 	if vars := GetVars(r); vars != nil {
 		return vars[k]
 	}
 
-	return chi.URLParam(r, k)
+	if val := chi.URLParam(r, k); val != "" {
+		return val
+	}
+
+	return r.PathValue(k)
 }
 
 // SetURLVars sets URL variables for testing purposes only.
@@ -108,3 +120,61 @@ func GetYAMLUnlimited(r io.ReadCloser, data interface{}) error {
 
 	return errors.WithStack(yaml.Unmarshal(bytes, data))
 }
+
+// GetBSON parses a BSON document from a io.ReadCloser (e.g.
+// http/*Request.Body or http/*Response.Body) into an object specified
+// by the request. Used in handler functiosn to retreve and parse data
+// submitted by the client.
+//
+// Returns an error if the body is greater than 16 megabytes in size.
+func GetBSON(r io.ReadCloser, data interface{}) error {
+	if r == nil {
+		return errors.New("no data defined")
+	}
+	defer r.Close()
+
+	bytes, err := ioutil.ReadAll(&io.LimitedReader{R: r, N: maxRequestSize})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(bson.Unmarshal(bytes, data))
+}
+
+// GetMsgpack parses a MessagePack document from a io.ReadCloser
+// (e.g. http/*Request.Body or http/*Response.Body) into an object
+// specified by the request. As with WriteMsgpackResponse, struct
+// fields use the names in their json struct tags.
+//
+// Returns an error if the body is greater than 16 megabytes in size.
+func GetMsgpack(r io.ReadCloser, data interface{}) error {
+	if r == nil {
+		return errors.New("no data defined")
+	}
+	defer r.Close()
+
+	dec := msgpack.NewDecoder(&io.LimitedReader{R: r, N: maxRequestSize})
+	dec.SetCustomStructTag("json")
+
+	return errors.WithStack(dec.Decode(data))
+}
+
+// GetXML parses an XML document from a io.ReadCloser (e.g.
+// http/*Request.Body or http/*Response.Body) into an object specified
+// by the request. Used in handler functiosn to retreve and parse data
+// submitted by the client.
+//
+// Returns an error if the body is greater than 16 megabytes in size.
+func GetXML(r io.ReadCloser, data interface{}) error {
+	if r == nil {
+		return errors.New("no data defined")
+	}
+	defer r.Close()
+
+	bytes, err := ioutil.ReadAll(&io.LimitedReader{R: r, N: maxRequestSize})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return errors.WithStack(xml.Unmarshal(bytes, data))
+}
@@ -0,0 +1,403 @@
+package gimlet
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/tychoish/grip"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.mongodb.org/mongo-driver/bson"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// maxMultipartMemory bounds the portion of multipart forms that
+// gimlet holds in memory, the remainder of the form is stored in
+// temporary files.
+const maxMultipartMemory = 32 * 1024 * 1024 // 32 MB
+
+// DecodeOptions configures the request body decoding performed by
+// DecodeRequest.
+type DecodeOptions struct {
+	// MaxSize bounds the size of the request body, in bytes. When
+	// zero, the limit for the route, as set with
+	// APIRoute.MaxRequestSize, or the default limit of 16
+	// megabytes applies.
+	MaxSize int64
+
+	// DisallowUnknownFields rejects JSON, YAML, and MessagePack
+	// documents that contain fields, and forms that contain
+	// values, which the destination does not define.
+	DisallowUnknownFields bool
+}
+
+// GetBody decodes the body of the request into the value that data
+// points to, choosing the decoder from the request's Content-Type,
+// as DecodeRequest does with the default options.
+func GetBody(r *http.Request, data interface{}) error {
+	return DecodeRequest(r, data, DecodeOptions{})
+}
+
+// DecodeRequest decodes the body of the request into the value that
+// data points to, choosing the decoder from the request's
+// Content-Type:
+//
+//   - JSON ("application/json", or any "+json" type), which is also
+//     the default for requests without a Content-Type,
+//
+//   - YAML ("application/yaml", "application/x-yaml", "text/yaml",
+//     or any "+yaml" type),
+//
+//   - URL encoded and multipart forms, which decode into url.Values,
+//     map[string]string, map[string][]string, or structs with "form"
+//     tags, as described for BindParams. Struct fields of the types
+//     *multipart.FileHeader and []*multipart.FileHeader receive the
+//     uploaded files, and
+//
+//   - CSV ("text/csv" or "application/csv"), which decodes into
+//     [][]string, []map[string]string keyed by the header row, or
+//     slices of structs with csv tags, as described for GetCSV,
+//
+//   - BSON ("application/bson"), MessagePack ("application/msgpack",
+//     "application/x-msgpack", or "application/vnd.msgpack"), and
+//     XML ("application/xml", "text/xml", or any "+xml" type), as
+//     with GetBSON, GetMsgpack, and GetXML.
+//
+// Bodies that cannot be decoded produce ErrorResponses with a 400
+// status, bodies larger than the size limit produce a 413 status,
+// and unsupported content types produce a 415 status.
+func DecodeRequest(r *http.Request, data interface{}, opts DecodeOptions) error {
+	if r.Body == nil {
+		return ErrorResponse{StatusCode: http.StatusBadRequest, Message: "request has no body"}
+	}
+	defer r.Body.Close()
+
+	if opts.MaxSize <= 0 {
+		opts.MaxSize = getMaxRequestSize(r.Context())
+	}
+	r.Body = http.MaxBytesReader(nil, r.Body, opts.MaxSize)
+
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			return ErrorResponse{
+				StatusCode: http.StatusUnsupportedMediaType,
+				Message:    fmt.Sprintf("'%s' is not a valid content type", ct),
+			}
+		}
+	}
+
+	switch {
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return decodeJSONBody(r, data, opts)
+	case stringSliceContains(YAML.mediaTypes(), mediaType) || strings.HasSuffix(mediaType, "+yaml"):
+		return decodeYAMLBody(r, data, opts)
+	case mediaType == "application/x-www-form-urlencoded":
+		if err := r.ParseForm(); err != nil {
+			return bodyError(err, "problem parsing form")
+		}
+		return decodeForm(r, data, opts)
+	case mediaType == "multipart/form-data":
+		if err := r.ParseMultipartForm(min(opts.MaxSize, maxMultipartMemory)); err != nil {
+			return bodyError(err, "problem parsing multipart form")
+		}
+		return decodeForm(r, data, opts)
+	case stringSliceContains(CSV.mediaTypes(), mediaType):
+		return decodeCSVBody(r, data)
+	case stringSliceContains(BSON.mediaTypes(), mediaType):
+		return decodeBSONBody(r, data)
+	case stringSliceContains(MSGPACK.mediaTypes(), mediaType):
+		return decodeMsgpackBody(r, data, opts)
+	case stringSliceContains(XML.mediaTypes(), mediaType) || strings.HasSuffix(mediaType, "+xml"):
+		return decodeXMLBody(r, data)
+	default:
+		return ErrorResponse{
+			StatusCode: http.StatusUnsupportedMediaType,
+			Message:    fmt.Sprintf("content type '%s' is not supported", mediaType),
+		}
+	}
+}
+
+// bodyError converts errors from reading or decoding a request body
+// into ErrorResponses.
+func bodyError(err error, msg string) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return ErrorResponse{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Message:    fmt.Sprintf("request body is larger than %d bytes", tooLarge.Limit),
+		}
+	}
+
+	return ErrorResponse{
+		StatusCode: http.StatusBadRequest,
+		Message:    errors.Wrap(err, msg).Error(),
+	}
+}
+
+func readBody(r *http.Request) ([]byte, error) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, bodyError(err, "problem reading request body")
+	}
+
+	if len(body) == 0 {
+		return nil, ErrorResponse{StatusCode: http.StatusBadRequest, Message: "request body is empty"}
+	}
+
+	return body, nil
+}
+
+func decodeJSONBody(r *http.Request, data interface{}, opts DecodeOptions) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+
+	if err := dec.Decode(data); err != nil {
+		return bodyError(err, "problem parsing JSON")
+	}
+
+	return nil
+}
+
+func decodeYAMLBody(r *http.Request, data interface{}, opts DecodeOptions) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	if opts.DisallowUnknownFields {
+		err = yaml.UnmarshalStrict(body, data)
+	} else {
+		err = yaml.Unmarshal(body, data)
+	}
+
+	if err != nil {
+		return bodyError(err, "problem parsing YAML")
+	}
+
+	return nil
+}
+
+func decodeBSONBody(r *http.Request, data interface{}) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	if err := bson.Unmarshal(body, data); err != nil {
+		return bodyError(err, "problem parsing BSON")
+	}
+
+	return nil
+}
+
+func decodeMsgpackBody(r *http.Request, data interface{}, opts DecodeOptions) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	dec := msgpack.NewDecoder(bytes.NewReader(body))
+	dec.SetCustomStructTag("json")
+	dec.DisallowUnknownFields(opts.DisallowUnknownFields)
+
+	if err := dec.Decode(data); err != nil {
+		return bodyError(err, "problem parsing MessagePack")
+	}
+
+	return nil
+}
+
+func decodeXMLBody(r *http.Request, data interface{}) error {
+	body, err := readBody(r)
+	if err != nil {
+		return err
+	}
+
+	if err := xml.Unmarshal(body, data); err != nil {
+		return bodyError(err, "problem parsing XML")
+	}
+
+	return nil
+}
+
+func decodeForm(r *http.Request, data interface{}, opts DecodeOptions) error {
+	switch out := data.(type) {
+	case *url.Values:
+		*out = r.PostForm
+		return nil
+	case *map[string][]string:
+		*out = r.PostForm
+		return nil
+	case *map[string]string:
+		*out = make(map[string]string, len(r.PostForm))
+		for k := range r.PostForm {
+			(*out)[k] = r.PostForm.Get(k)
+		}
+		return nil
+	}
+
+	val := reflect.ValueOf(data)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("cannot decode form into %T", data)
+	}
+
+	var failures []FieldError
+	if err := bindParams(r, val.Elem(), []string{ParamSourceForm}, &failures); err != nil {
+		return err
+	}
+
+	if opts.DisallowUnknownFields {
+		known := map[string]struct{}{}
+		formKeys(val.Elem().Type(), known)
+
+		keys := make([]string, 0, len(r.PostForm))
+		for k := range r.PostForm {
+			keys = append(keys, k)
+		}
+		if r.MultipartForm != nil {
+			for k := range r.MultipartForm.File {
+				keys = append(keys, k)
+			}
+		}
+
+		for _, k := range keys {
+			if _, ok := known[k]; !ok {
+				failures = append(failures, FieldError{Field: k, Source: ParamSourceForm, Message: "is not a known field"})
+			}
+		}
+	}
+
+	if len(failures) > 0 {
+		return paramError(failures...)
+	}
+
+	return nil
+}
+
+func formKeys(t reflect.Type, keys map[string]struct{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if source, key := paramTag(field); source == ParamSourceForm {
+			keys[key] = struct{}{}
+		} else if source == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			formKeys(field.Type, keys)
+		}
+	}
+}
+
+var fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+
+func isFormFileType(t reflect.Type) bool {
+	return t == fileHeaderType || (t.Kind() == reflect.Slice && t.Elem() == fileHeaderType)
+}
+
+// setFormFiles sets the field to the files uploaded with the key,
+// and returns false if the request has no such files.
+func setFormFiles(r *http.Request, field reflect.Value, key string) bool {
+	if r.MultipartForm == nil || len(r.MultipartForm.File[key]) == 0 {
+		return false
+	}
+
+	files := r.MultipartForm.File[key]
+	if field.Kind() == reflect.Slice {
+		field.Set(reflect.ValueOf(files))
+	} else {
+		field.Set(reflect.ValueOf(files[0]))
+	}
+
+	return true
+}
+
+func decodeCSVBody(r *http.Request, data interface{}) error {
+	switch data.(type) {
+	case *[][]string, *[]map[string]string:
+	default:
+		if _, err := getCSVElemType(reflect.ValueOf(data)); err != nil {
+			return errors.Wrapf(err, "cannot decode CSV into %T", data)
+		}
+
+		if err := GetCSV(r.Body, data); err != nil {
+			if _, ok := err.(ErrorResponse); ok {
+				return err
+			}
+			return bodyError(err, "problem parsing CSV")
+		}
+		return nil
+	}
+
+	records, err := csv.NewReader(r.Body).ReadAll()
+	if err != nil {
+		return bodyError(err, "problem parsing CSV")
+	}
+
+	switch out := data.(type) {
+	case *[][]string:
+		*out = records
+	case *[]map[string]string:
+		if len(records) == 0 {
+			*out = nil
+			return nil
+		}
+
+		rows := make([]map[string]string, 0, len(records)-1)
+		for _, record := range records[1:] {
+			row := make(map[string]string, len(record))
+			for idx, name := range records[0] {
+				row[name] = record[idx]
+			}
+			rows = append(rows, row)
+		}
+		*out = rows
+	default:
+		return errors.Errorf("cannot decode CSV into %T", data)
+	}
+
+	return nil
+}
+
+// MaxRequestSize sets the limit, in bytes, on the size of request
+// bodies that GetBody and DecodeRequest read for this route, in place
+// of the default limit of 16 megabytes.
+func (r *APIRoute) MaxRequestSize(size int64) *APIRoute {
+	if size <= 0 {
+		grip.Warningf("%d is not a valid request size for route %s", size, r.route)
+		return r
+	}
+
+	r.maxRequestSize = size
+	return r
+}
+
+func withMaxRequestSize(size int64, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), maxRequestSizeKey, size)))
+	})
+}
+
+func getMaxRequestSize(ctx context.Context) int64 {
+	if size, ok := ctx.Value(maxRequestSizeKey).(int64); ok && size > 0 {
+		return size
+	}
+
+	return maxRequestSize
+}
@@ -0,0 +1,162 @@
+package gimlet
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type bodyTestInput struct {
+	Name  string `json:"name" yaml:"name" form:"name" validate:"required"`
+	Count int    `json:"count" yaml:"count" form:"count"`
+}
+
+type bodyTestUpload struct {
+	bodyTestInput
+	File  *multipart.FileHeader   `form:"file"`
+	Files []*multipart.FileHeader `form:"extra"`
+}
+
+func newBodyRequest(contentType, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req
+}
+
+func bodyErrorStatus(t *testing.T, err error) int {
+	t.Helper()
+	require.Error(t, err)
+	resp, ok := err.(ErrorResponse)
+	require.True(t, ok, "%T", err)
+	return resp.StatusCode
+}
+
+func TestGetBody(t *testing.T) {
+	t.Run("JSON", func(t *testing.T) {
+		for _, ct := range []string{"", "application/json", "application/json; charset=utf-8", "application/vnd.example.v1+json"} {
+			out := bodyTestInput{}
+			require.NoError(t, GetBody(newBodyRequest(ct, `{"name": "a", "count": 2}`), &out), ct)
+			assert.Equal(t, bodyTestInput{Name: "a", Count: 2}, out)
+		}
+	})
+	t.Run("YAML", func(t *testing.T) {
+		for _, ct := range []string{"application/yaml", "application/x-yaml", "text/yaml"} {
+			out := bodyTestInput{}
+			require.NoError(t, GetBody(newBodyRequest(ct, "name: a\ncount: 2\n"), &out), ct)
+			assert.Equal(t, bodyTestInput{Name: "a", Count: 2}, out)
+		}
+	})
+	t.Run("Form", func(t *testing.T) {
+		out := bodyTestInput{}
+		require.NoError(t, GetBody(newBodyRequest("application/x-www-form-urlencoded", "name=a&count=2"), &out))
+		assert.Equal(t, bodyTestInput{Name: "a", Count: 2}, out)
+
+		values := url.Values{}
+		require.NoError(t, GetBody(newBodyRequest("application/x-www-form-urlencoded", "name=a&name=b"), &values))
+		assert.Equal(t, []string{"a", "b"}, values["name"])
+
+		flat := map[string]string{}
+		require.NoError(t, GetBody(newBodyRequest("application/x-www-form-urlencoded", "name=a&name=b"), &flat))
+		assert.Equal(t, map[string]string{"name": "a"}, flat)
+
+		err := GetBody(newBodyRequest("application/x-www-form-urlencoded", "count=x"), &out)
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, err))
+		assert.Len(t, err.(ErrorResponse).Fields, 2)
+	})
+	t.Run("Multipart", func(t *testing.T) {
+		buf := &bytes.Buffer{}
+		mw := multipart.NewWriter(buf)
+		require.NoError(t, mw.WriteField("name", "a"))
+		require.NoError(t, mw.WriteField("count", "3"))
+		fw, err := mw.CreateFormFile("file", "data.txt")
+		require.NoError(t, err)
+		_, err = fw.Write([]byte("hello"))
+		require.NoError(t, err)
+		for _, name := range []string{"one.txt", "two.txt"} {
+			_, err = mw.CreateFormFile("extra", name)
+			require.NoError(t, err)
+		}
+		require.NoError(t, mw.Close())
+
+		out := bodyTestUpload{}
+		require.NoError(t, GetBody(newBodyRequest(mw.FormDataContentType(), buf.String()), &out))
+		assert.Equal(t, bodyTestInput{Name: "a", Count: 3}, out.bodyTestInput)
+		require.NotNil(t, out.File)
+		assert.Equal(t, "data.txt", out.File.Filename)
+		assert.Equal(t, int64(5), out.File.Size)
+		assert.Len(t, out.Files, 2)
+	})
+	t.Run("CSV", func(t *testing.T) {
+		records := [][]string{}
+		require.NoError(t, GetBody(newBodyRequest("text/csv", "name,count\na,1\nb,2\n"), &records))
+		assert.Len(t, records, 3)
+
+		rows := []map[string]string{}
+		require.NoError(t, GetBody(newBodyRequest("application/csv", "name,count\na,1\nb,2\n"), &rows))
+		assert.Equal(t, []map[string]string{{"name": "a", "count": "1"}, {"name": "b", "count": "2"}}, rows)
+
+		imports := []sampleImport{}
+		require.NoError(t, GetBody(newBodyRequest("text/csv", "name,count\na,1\nb,2\n"), &imports))
+		require.Len(t, imports, 2)
+		assert.Equal(t, 2, imports[1].Count)
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, GetBody(newBodyRequest("text/csv", "name,count\na,x\n"), &imports)))
+
+		assert.Error(t, GetBody(newBodyRequest("text/csv", "name,count\na,1\n"), &bodyTestInput{}))
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, GetBody(newBodyRequest("text/csv", "name,count\na\n"), &records)))
+	})
+	t.Run("UnknownFields", func(t *testing.T) {
+		opts := DecodeOptions{DisallowUnknownFields: true}
+		out := bodyTestInput{}
+
+		require.NoError(t, DecodeRequest(newBodyRequest("", `{"name": "a", "other": 1}`), &out, DecodeOptions{}))
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, DecodeRequest(newBodyRequest("", `{"name": "a", "other": 1}`), &out, opts)))
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, DecodeRequest(newBodyRequest("application/yaml", "name: a\nother: 1\n"), &out, opts)))
+
+		err := DecodeRequest(newBodyRequest("application/x-www-form-urlencoded", "name=a&other=1"), &out, opts)
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, err))
+		require.Len(t, err.(ErrorResponse).Fields, 1)
+		assert.Equal(t, "other", err.(ErrorResponse).Fields[0].Field)
+	})
+	t.Run("Errors", func(t *testing.T) {
+		out := bodyTestInput{}
+		assert.Equal(t, http.StatusUnsupportedMediaType, bodyErrorStatus(t, GetBody(newBodyRequest("application/pdf", "%PDF"), &out)))
+		assert.Equal(t, http.StatusUnsupportedMediaType, bodyErrorStatus(t, GetBody(newBodyRequest("not a type;;", "{}"), &out)))
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, GetBody(newBodyRequest("", ""), &out)))
+		assert.Equal(t, http.StatusBadRequest, bodyErrorStatus(t, GetBody(newBodyRequest("", "{"), &out)))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, bodyErrorStatus(t, DecodeRequest(newBodyRequest("", `{"name": "abcdef"}`), &out, DecodeOptions{MaxSize: 8})))
+		assert.Equal(t, http.StatusRequestEntityTooLarge, bodyErrorStatus(t, DecodeRequest(newBodyRequest("application/x-www-form-urlencoded", "name=abcdef"), &out, DecodeOptions{MaxSize: 4})))
+	})
+	t.Run("RouteLimit", func(t *testing.T) {
+		app := NewApp()
+		app.AddRoute("/small").Version(1).Post().MaxRequestSize(8).Handler(func(rw http.ResponseWriter, r *http.Request) {
+			out := bodyTestInput{}
+			if err := GetBody(r, &out); err != nil {
+				WriteResponse(rw, MakeJSONErrorResponder(err))
+				return
+			}
+			WriteJSON(rw, out)
+		})
+
+		h, err := app.Handler()
+		require.NoError(t, err)
+
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/v1/small", strings.NewReader(`{"name": "abcdef"}`))
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, rw.Code)
+
+		rw = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, "/v1/small", strings.NewReader(`{}`))
+		h.ServeHTTP(rw, req)
+		assert.Equal(t, http.StatusOK, rw.Code)
+	})
+}
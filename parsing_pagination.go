@@ -0,0 +1,257 @@
+package gimlet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/pkg/errors"
+)
+
+// PaginationMode determines how requests identify pages of a
+// collection.
+type PaginationMode int
+
+const (
+	// PaginationKey identifies pages by the key of their first
+	// item, or, with a cursor secret, by an opaque cursor.
+	PaginationKey PaginationMode = iota
+	// PaginationOffset identifies pages by the offset of their
+	// first item.
+	PaginationOffset
+)
+
+const (
+	defaultPaginationLimit    = 100
+	defaultPaginationMaxLimit = 1000
+)
+
+// PaginationOptions configures ParsePagination. The zero value reads
+// the "key" and "limit" query parameters, with a default limit of 100
+// and a maximum limit of 1000.
+type PaginationOptions struct {
+	Mode PaginationMode
+
+	// KeyParam, LimitParam and OffsetParam are the names of the
+	// query parameters, which default to "key", "limit" and
+	// "offset".
+	KeyParam    string
+	LimitParam  string
+	OffsetParam string
+
+	// DefaultLimit is the limit of requests that do not specify
+	// a limit, and MaxLimit is the largest limit that requests
+	// may specify.
+	DefaultLimit int
+	MaxLimit     int
+
+	// CursorSecret, when set, makes keys opaque cursors, signed
+	// with this secret, so that clients cannot construct or
+	// modify keys. ParsePagination rejects requests with invalid
+	// cursors.
+	CursorSecret []byte
+
+	// BaseURL is the base of the URLs in pagination links, and
+	// defaults to the scheme and host of the request.
+	BaseURL string
+}
+
+func (opts *PaginationOptions) setDefaults() {
+	if opts.KeyParam == "" {
+		opts.KeyParam = "key"
+	}
+	if opts.LimitParam == "" {
+		opts.LimitParam = "limit"
+	}
+	if opts.OffsetParam == "" {
+		opts.OffsetParam = "offset"
+	}
+	if opts.MaxLimit <= 0 {
+		opts.MaxLimit = defaultPaginationMaxLimit
+	}
+	if opts.DefaultLimit <= 0 {
+		opts.DefaultLimit = min(defaultPaginationLimit, opts.MaxLimit)
+	}
+}
+
+// Pagination describes the page of a collection that a request asks
+// for, as returned by ParsePagination. Use KeyPages or OffsetPages to
+// build the pagination metadata of the response, which RouteHandlers
+// write in the Link and X-Total-Count headers.
+type Pagination struct {
+	// Key is the key of the first item of the page, decoded from
+	// the cursor if the pagination uses cursors, and is empty for
+	// the first page.
+	Key    string
+	Limit  int
+	Offset int
+
+	opts PaginationOptions
+}
+
+// ParsePagination reads the key or offset, and the limit, of the
+// requested page from the query parameters of the request. Requests
+// with limits outside of the bounds in the options, negative offsets,
+// or invalid cursors receive 400 errors.
+func ParsePagination(r *http.Request, opts PaginationOptions) (*Pagination, error) {
+	opts.setDefaults()
+	if opts.BaseURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		opts.BaseURL = fmt.Sprintf("%s://%s", scheme, r.Host)
+	}
+
+	p := &Pagination{Limit: opts.DefaultLimit, opts: opts}
+	query := r.URL.Query()
+	failures := []FieldError{}
+
+	if value := query.Get(opts.LimitParam); value != "" {
+		limit, err := strconv.Atoi(value)
+		switch {
+		case err != nil:
+			failures = append(failures, FieldError{Field: opts.LimitParam, Source: ParamSourceQuery, Message: "must be an integer"})
+		case limit < 1 || limit > opts.MaxLimit:
+			failures = append(failures, FieldError{Field: opts.LimitParam, Source: ParamSourceQuery, Message: fmt.Sprintf("must be between 1 and %d", opts.MaxLimit)})
+		default:
+			p.Limit = limit
+		}
+	}
+
+	switch opts.Mode {
+	case PaginationOffset:
+		if value := query.Get(opts.OffsetParam); value != "" {
+			offset, err := strconv.Atoi(value)
+			if err != nil || offset < 0 {
+				failures = append(failures, FieldError{Field: opts.OffsetParam, Source: ParamSourceQuery, Message: "must be a non-negative integer"})
+			} else {
+				p.Offset = offset
+			}
+		}
+	default:
+		p.Key = query.Get(opts.KeyParam)
+		if p.Key != "" && len(opts.CursorSecret) > 0 {
+			key, err := DecodeCursor(opts.CursorSecret, p.Key)
+			if err != nil {
+				failures = append(failures, FieldError{Field: opts.KeyParam, Source: ParamSourceQuery, Message: "is not a valid cursor"})
+			}
+			p.Key = key
+		}
+	}
+
+	if len(failures) > 0 {
+		return nil, paramError(failures...)
+	}
+
+	return p, nil
+}
+
+func (p *Pagination) page(relation string) *Page {
+	page := &Page{
+		BaseURL:         p.opts.BaseURL,
+		KeyQueryParam:   p.opts.KeyParam,
+		LimitQueryParam: p.opts.LimitParam,
+		Limit:           p.Limit,
+		Relation:        relation,
+	}
+
+	if p.opts.Mode == PaginationOffset {
+		page.OffsetQueryParam = p.opts.OffsetParam
+	}
+
+	return page
+}
+
+func (p *Pagination) keyPage(relation, key string) *Page {
+	page := p.page(relation)
+	if key != "" && len(p.opts.CursorSecret) > 0 {
+		key = EncodeCursor(p.opts.CursorSecret, key)
+	}
+	page.Key = key
+
+	return page
+}
+
+func (p *Pagination) offsetPage(relation string, offset int) *Page {
+	page := p.page(relation)
+	page.Offset = offset
+
+	return page
+}
+
+// KeyPages returns the pagination metadata for a page of a keyed
+// collection: next and prev are the keys of the first items of the
+// adjacent pages, which are empty if there is no such page. The
+// metadata always include the first page, and encode the keys as
+// cursors if the pagination uses cursors.
+func (p *Pagination) KeyPages(next, prev string) *ResponsePages {
+	pages := &ResponsePages{First: p.keyPage("first", "")}
+
+	if next != "" {
+		pages.Next = p.keyPage("next", next)
+	}
+
+	if prev != "" {
+		pages.Prev = p.keyPage("prev", prev)
+	}
+
+	return pages
+}
+
+// OffsetPages returns the pagination metadata for a page of a
+// collection with the given total number of items, including the
+// first and last pages, and the total count. When the total is
+// negative (i.e. unknown), the metadata omit the last page and the
+// total count, and always include the next page.
+func (p *Pagination) OffsetPages(total int) *ResponsePages {
+	pages := &ResponsePages{First: p.offsetPage("first", 0)}
+
+	if p.Offset > 0 {
+		pages.Prev = p.offsetPage("prev", max(p.Offset-p.Limit, 0))
+	}
+
+	if total < 0 {
+		pages.Next = p.offsetPage("next", p.Offset+p.Limit)
+		return pages
+	}
+
+	if p.Offset+p.Limit < total {
+		pages.Next = p.offsetPage("next", p.Offset+p.Limit)
+	}
+
+	pages.Last = p.offsetPage("last", max(total-1, 0)/p.Limit*p.Limit)
+	pages.TotalCount = &total
+
+	return pages
+}
+
+// EncodeCursor encodes a key as an opaque pagination cursor, signed
+// with the secret.
+func EncodeCursor(secret []byte, key string) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write([]byte(key))
+
+	return base64.RawURLEncoding.EncodeToString(append(mac.Sum(nil), key...))
+}
+
+// DecodeCursor returns the key of a cursor produced by EncodeCursor,
+// returning an error if the cursor is malformed or was not signed
+// with the secret.
+func DecodeCursor(secret []byte, cursor string) (string, error) {
+	payload, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil || len(payload) < sha256.Size {
+		return "", errors.New("invalid pagination cursor")
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(payload[sha256.Size:])
+	if !hmac.Equal(mac.Sum(nil), payload[:sha256.Size]) {
+		return "", errors.New("invalid pagination cursor")
+	}
+
+	return string(payload[sha256.Size:]), nil
+}
@@ -0,0 +1,178 @@
+package gimlet
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type paginationTestHandler struct {
+	opts  PaginationOptions
+	total int
+	page  *Pagination
+}
+
+func (h *paginationTestHandler) Factory() RouteHandler {
+	return &paginationTestHandler{opts: h.opts, total: h.total}
+}
+func (h *paginationTestHandler) Parse(ctx context.Context, r *http.Request) error {
+	var err error
+	h.page, err = ParsePagination(r, h.opts)
+	return err
+}
+func (h *paginationTestHandler) Run(ctx context.Context) Responder {
+	resp := NewJSONResponse([]int{})
+	if err := resp.SetPages(h.page.OffsetPages(h.total)); err != nil {
+		return MakeJSONInternalErrorResponder(err)
+	}
+	return resp
+}
+
+func parseLinks(t *testing.T, header string) map[string]url.Values {
+	out := map[string]url.Values{}
+	for _, link := range strings.Split(header, ",") {
+		parts := strings.SplitN(link, ">; rel=", 2)
+		require.Len(t, parts, 2)
+		u, err := url.Parse(strings.TrimPrefix(parts[0], "<"))
+		require.NoError(t, err)
+		out[strings.Trim(parts[1], `"`)] = u.Query()
+	}
+	return out
+}
+
+func TestParsePagination(t *testing.T) {
+	t.Run("Defaults", func(t *testing.T) {
+		p, err := ParsePagination(httptest.NewRequest(http.MethodGet, "/items", nil), PaginationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "", p.Key)
+		assert.Equal(t, 100, p.Limit)
+
+		p, err = ParsePagination(httptest.NewRequest(http.MethodGet, "/items?key=abc&limit=5", nil), PaginationOptions{})
+		require.NoError(t, err)
+		assert.Equal(t, "abc", p.Key)
+		assert.Equal(t, 5, p.Limit)
+
+		p, err = ParsePagination(httptest.NewRequest(http.MethodGet, "/items", nil), PaginationOptions{MaxLimit: 10})
+		require.NoError(t, err)
+		assert.Equal(t, 10, p.Limit)
+	})
+	t.Run("Bounds", func(t *testing.T) {
+		for _, query := range []string{"limit=0", "limit=1001", "limit=many", "offset=-1", "offset=first"} {
+			_, err := ParsePagination(httptest.NewRequest(http.MethodGet, "/items?"+query, nil), PaginationOptions{Mode: PaginationOffset})
+			require.Error(t, err, query)
+			eresp, ok := err.(ErrorResponse)
+			require.True(t, ok)
+			assert.Equal(t, http.StatusBadRequest, eresp.StatusCode)
+			assert.Len(t, eresp.Fields, 1)
+		}
+
+		p, err := ParsePagination(httptest.NewRequest(http.MethodGet, "/items?l=20&o=40", nil), PaginationOptions{
+			Mode:        PaginationOffset,
+			LimitParam:  "l",
+			OffsetParam: "o",
+			MaxLimit:    20,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, 20, p.Limit)
+		assert.Equal(t, 40, p.Offset)
+	})
+	t.Run("Cursors", func(t *testing.T) {
+		secret := []byte("secret")
+		cursor := EncodeCursor(secret, "2020-01-01/item-42")
+		assert.NotContains(t, cursor, "item-42")
+
+		key, err := DecodeCursor(secret, cursor)
+		require.NoError(t, err)
+		assert.Equal(t, "2020-01-01/item-42", key)
+
+		_, err = DecodeCursor([]byte("other"), cursor)
+		assert.Error(t, err)
+		_, err = DecodeCursor(secret, "not*base64")
+		assert.Error(t, err)
+		_, err = DecodeCursor(secret, EncodeCursor(secret, "a")[:10])
+		assert.Error(t, err)
+
+		opts := PaginationOptions{CursorSecret: secret}
+		p, err := ParsePagination(httptest.NewRequest(http.MethodGet, "/items?key="+cursor, nil), opts)
+		require.NoError(t, err)
+		assert.Equal(t, "2020-01-01/item-42", p.Key)
+
+		_, err = ParsePagination(httptest.NewRequest(http.MethodGet, "/items?key=item-42", nil), opts)
+		assert.Error(t, err)
+
+		pages := p.KeyPages("item-52", "item-32")
+		require.NoError(t, pages.Validate())
+		links := parseLinks(t, pages.GetLinks("/items?key="+cursor))
+		next, err := DecodeCursor(secret, links["next"].Get("key"))
+		require.NoError(t, err)
+		assert.Equal(t, "item-52", next)
+		assert.NotEmpty(t, links["prev"].Get("key"))
+		assert.NotContains(t, links["first"], "key")
+		assert.NotContains(t, links, "last")
+	})
+	t.Run("OffsetPages", func(t *testing.T) {
+		p, err := ParsePagination(httptest.NewRequest(http.MethodGet, "/items?offset=20&limit=10", nil), PaginationOptions{Mode: PaginationOffset})
+		require.NoError(t, err)
+
+		pages := p.OffsetPages(45)
+		require.NoError(t, pages.Validate())
+		assert.Equal(t, 45, *pages.TotalCount)
+		for rel, offset := range map[string]int{"next": 30, "prev": 10, "first": 0, "last": 40} {
+			page := map[string]*Page{"next": pages.Next, "prev": pages.Prev, "first": pages.First, "last": pages.Last}[rel]
+			require.NotNil(t, page, rel)
+			assert.Equal(t, offset, page.Offset, rel)
+		}
+
+		pages = p.OffsetPages(30)
+		assert.Nil(t, pages.Next)
+		assert.Equal(t, 20, pages.Last.Offset)
+
+		pages = p.OffsetPages(0)
+		assert.Equal(t, 0, pages.Last.Offset)
+
+		pages = p.OffsetPages(-1)
+		assert.NotNil(t, pages.Next)
+		assert.Nil(t, pages.Last)
+		assert.Nil(t, pages.TotalCount)
+	})
+	t.Run("Handler", func(t *testing.T) {
+		handler := handleHandler(&paginationTestHandler{opts: PaginationOptions{Mode: PaginationOffset}, total: 25})
+
+		rw := httptest.NewRecorder()
+		handler(rw, httptest.NewRequest(http.MethodGet, "http://example.com/items?filter=a&offset=10&limit=10", nil))
+		require.Equal(t, http.StatusOK, rw.Code)
+		assert.Equal(t, "25", rw.Header().Get("X-Total-Count"))
+		assert.True(t, strings.HasPrefix(rw.Header().Get("Link"), "<http://example.com/items?filter=a&limit=10&offset=20>; rel=\"next\""))
+
+		links := parseLinks(t, rw.Header().Get("Link"))
+		assert.Equal(t, "0", links["prev"].Get("offset"))
+		assert.Equal(t, "0", links["first"].Get("offset"))
+		assert.Equal(t, "20", links["last"].Get("offset"))
+		assert.Equal(t, "a", links["last"].Get("filter"))
+
+		rw = httptest.NewRecorder()
+		handler(rw, httptest.NewRequest(http.MethodGet, "/items?limit=5000", nil))
+		assert.Equal(t, http.StatusBadRequest, rw.Code)
+	})
+	t.Run("OffsetPageValidation", func(t *testing.T) {
+		page := &Page{BaseURL: "http://example.com", LimitQueryParam: "limit", OffsetQueryParam: "offset", Relation: "next"}
+		assert.NoError(t, page.Validate())
+
+		page.Offset = -1
+		assert.Error(t, page.Validate())
+
+		page = &Page{BaseURL: "http://example.com", KeyQueryParam: "key", LimitQueryParam: "limit", Relation: "next"}
+		assert.Error(t, page.Validate())
+		page.Relation = "first"
+		assert.NoError(t, page.Validate())
+
+		total := -1
+		assert.Error(t, (&ResponsePages{TotalCount: &total}).Validate())
+	})
+}
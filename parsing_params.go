@@ -0,0 +1,393 @@
+package gimlet
+
+import (
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Sources of request parameters, as used in the struct tags that
+// BindParams reads and in FieldError.Source.
+const (
+	ParamSourcePath   = "path"
+	ParamSourceQuery  = "query"
+	ParamSourceHeader = "header"
+	ParamSourceForm   = "form"
+)
+
+// PathParam returns the URL parameter for the key, from any router,
+// converted to the type. Missing or invalid values produce an
+// ErrorResponse with a 400 status.
+func PathParam[T any](r *http.Request, key string) (T, error) {
+	return typedParam[T](ParamSourcePath, key, paramValues(r, ParamSourcePath, key))
+}
+
+// QueryParam returns the query parameter for the key, converted to
+// the type. Slice types collect all values for the key. Missing or
+// invalid values produce an ErrorResponse with a 400 status.
+func QueryParam[T any](r *http.Request, key string) (T, error) {
+	return typedParam[T](ParamSourceQuery, key, paramValues(r, ParamSourceQuery, key))
+}
+
+// HeaderParam returns the value of the header, converted to the
+// type. Slice types collect all values for the header. Missing or
+// invalid values produce an ErrorResponse with a 400 status.
+func HeaderParam[T any](r *http.Request, key string) (T, error) {
+	return typedParam[T](ParamSourceHeader, key, paramValues(r, ParamSourceHeader, key))
+}
+
+func typedParam[T any](source, key string, values []string) (T, error) {
+	var out T
+
+	if len(values) == 0 {
+		return out, paramError(FieldError{Field: key, Source: source, Message: "is required"})
+	}
+
+	if err := setParam(reflect.ValueOf(&out).Elem(), values); err != nil {
+		return out, paramError(FieldError{Field: key, Source: source, Message: err.Error()})
+	}
+
+	return out, nil
+}
+
+func paramValues(r *http.Request, source, key string) []string {
+	switch source {
+	case ParamSourcePath:
+		if value := GetParam(r, key); value != "" {
+			return []string{value}
+		}
+		return nil
+	case ParamSourceQuery:
+		return r.URL.Query()[key]
+	case ParamSourceHeader:
+		return r.Header.Values(key)
+	case ParamSourceForm:
+		return r.PostForm[key]
+	default:
+		return nil
+	}
+}
+
+func paramError(fields ...FieldError) error {
+	msgs := make([]string, 0, len(fields))
+	for _, f := range fields {
+		msgs = append(msgs, f.Error())
+	}
+
+	return ErrorResponse{
+		StatusCode: http.StatusBadRequest,
+		Message:    fmt.Sprintf("invalid parameters: %s", strings.Join(msgs, "; ")),
+		Fields:     fields,
+	}
+}
+
+// BindParams populates the fields of the struct that dst points to
+// from the request's URL parameters, query string, and headers. Tag
+// fields with the source and name of their parameter, and
+// optionally with a default value and validation constraints:
+//
+//	type params struct {
+//		ID    int      `path:"id" validate:"required,min=1"`
+//		Limit int      `query:"limit" default:"10" validate:"min=1,max=100"`
+//		Sort  string   `query:"sort" validate:"enum=asc|desc"`
+//		Tags  []string `query:"tag"`
+//		Token string   `header:"X-Token" validate:"regex=^[a-z0-9]+$"`
+//	}
+//
+// Supported constraints are "required", "min" and "max" (which
+// compare numbers, or the length of strings and slices), "enum"
+// (alternatives separated by "|"), and "regex", which must be the
+// last constraint. Fields may be strings, numbers, booleans,
+// time.Duration, time.Time (RFC 3339), types that implement
+// encoding.TextUnmarshaler (e.g. UUIDs), or slices and pointers of
+// these types. Embedded structs are bound recursively.
+//
+// If any field is missing or invalid, BindParams returns an
+// ErrorResponse with a 400 status that lists every failure.
+func BindParams(r *http.Request, dst interface{}) error {
+	val := reflect.ValueOf(dst)
+	if val.Kind() != reflect.Ptr || val.IsNil() || val.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("cannot bind parameters to %T, which is not a pointer to a struct", dst)
+	}
+
+	var failures []FieldError
+	if err := bindParams(r, val.Elem(), paramSources, &failures); err != nil {
+		return err
+	}
+
+	if len(failures) > 0 {
+		return paramError(failures...)
+	}
+
+	return nil
+}
+
+// paramSources are the sources of the parameters that BindParams
+// binds: form values are part of the request body, which GetBody
+// decodes.
+var paramSources = []string{ParamSourcePath, ParamSourceQuery, ParamSourceHeader}
+
+func bindParams(r *http.Request, val reflect.Value, sources []string, failures *[]FieldError) error {
+	t := val.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		source, key := paramTag(field)
+		if !stringSliceContains(sources, source) {
+			if source == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+				if err := bindParams(r, val.Field(i), sources, failures); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			return errors.Errorf("cannot bind parameter '%s' to unexported field %s", key, field.Name)
+		}
+
+		constraints, err := parseConstraints(field.Tag.Get("validate"))
+		if err != nil {
+			return errors.Wrapf(err, "invalid constraints for field %s", field.Name)
+		}
+
+		if source == ParamSourceForm && isFormFileType(field.Type) {
+			if !setFormFiles(r, val.Field(i), key) && constraints.required {
+				*failures = append(*failures, FieldError{Field: key, Source: source, Message: "is required"})
+			}
+			continue
+		}
+
+		values := paramValues(r, source, key)
+		if len(values) == 0 {
+			if def, ok := field.Tag.Lookup("default"); ok {
+				values = []string{def}
+			}
+		}
+
+		if len(values) == 0 {
+			if constraints.required {
+				*failures = append(*failures, FieldError{Field: key, Source: source, Message: "is required"})
+			}
+			continue
+		}
+
+		if err := setParam(val.Field(i), values); err != nil {
+			*failures = append(*failures, FieldError{Field: key, Source: source, Message: err.Error()})
+			continue
+		}
+
+		for _, msg := range constraints.check(val.Field(i), values) {
+			*failures = append(*failures, FieldError{Field: key, Source: source, Message: msg})
+		}
+	}
+
+	return nil
+}
+
+func paramTag(field reflect.StructField) (string, string) {
+	for _, source := range []string{ParamSourcePath, ParamSourceQuery, ParamSourceHeader, ParamSourceForm} {
+		if key, ok := field.Tag.Lookup(source); ok && key != "" && key != "-" {
+			return source, key
+		}
+	}
+
+	return "", ""
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// setParam converts the values to the type of the field, and sets
+// the field. Slices receive all values, other types receive the
+// first value.
+func setParam(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Ptr {
+		ptr := reflect.New(field.Type().Elem())
+		if err := setParam(ptr.Elem(), values); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		out := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for idx, value := range values {
+			if err := setParamValue(out.Index(idx), value); err != nil {
+				return err
+			}
+		}
+		field.Set(out)
+		return nil
+	}
+
+	return setParamValue(field, values[0])
+}
+
+func setParamValue(field reflect.Value, value string) error {
+	if field.CanAddr() && field.Addr().Type().Implements(textUnmarshalerType) {
+		if err := field.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value)); err != nil {
+			return errors.Errorf("'%s' is not valid: %s", value, err)
+		}
+		return nil
+	}
+
+	switch field.Interface().(type) {
+	case time.Duration:
+		dur, err := time.ParseDuration(value)
+		if err != nil {
+			return errors.Errorf("'%s' is not a valid duration", value)
+		}
+		field.SetInt(int64(dur))
+		return nil
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return errors.Errorf("'%s' is not a valid boolean", value)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("'%s' is not a valid integer", value)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("'%s' is not a valid unsigned integer", value)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return errors.Errorf("'%s' is not a valid number", value)
+		}
+		field.SetFloat(n)
+	default:
+		return errors.Errorf("parameters of type %s are not supported", field.Type())
+	}
+
+	return nil
+}
+
+type paramConstraints struct {
+	required bool
+	min      *float64
+	max      *float64
+	enum     []string
+	regex    *regexp.Regexp
+}
+
+func parseConstraints(tag string) (paramConstraints, error) {
+	var out paramConstraints
+
+	for tag != "" {
+		var opt string
+		if strings.HasPrefix(tag, "regex=") {
+			// regular expressions may contain commas, so
+			// they consume the remainder of the tag.
+			opt, tag = tag, ""
+		} else {
+			opt, tag, _ = strings.Cut(tag, ",")
+		}
+
+		name, value, _ := strings.Cut(strings.TrimSpace(opt), "=")
+		switch name {
+		case "":
+		case "required":
+			out.required = true
+		case "min", "max":
+			n, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return out, errors.Errorf("'%s' is not a valid %s", value, name)
+			}
+			if name == "min" {
+				out.min = &n
+			} else {
+				out.max = &n
+			}
+		case "enum":
+			out.enum = strings.Split(value, "|")
+		case "regex":
+			re, err := regexp.Compile(value)
+			if err != nil {
+				return out, errors.Wrapf(err, "invalid regex '%s'", value)
+			}
+			out.regex = re
+		default:
+			return out, errors.Errorf("'%s' is not a supported constraint", name)
+		}
+	}
+
+	return out, nil
+}
+
+// check returns messages describing the constraints that the value
+// violates.
+func (c paramConstraints) check(field reflect.Value, values []string) []string {
+	var out []string
+
+	for field.Kind() == reflect.Ptr {
+		field = field.Elem()
+	}
+
+	if c.min != nil || c.max != nil {
+		var (
+			n    float64
+			noun = ""
+		)
+
+		switch field.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			n = float64(field.Int())
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			n = float64(field.Uint())
+		case reflect.Float32, reflect.Float64:
+			n = field.Float()
+		case reflect.String, reflect.Slice:
+			n, noun = float64(field.Len()), "length "
+		}
+
+		if c.min != nil && n < *c.min {
+			out = append(out, fmt.Sprintf("%smust be at least %v", noun, *c.min))
+		}
+		if c.max != nil && n > *c.max {
+			out = append(out, fmt.Sprintf("%smust be at most %v", noun, *c.max))
+		}
+	}
+
+	for _, value := range values {
+		if len(c.enum) > 0 && !stringSliceContains(c.enum, value) {
+			out = append(out, fmt.Sprintf("'%s' must be one of [%s]", value, strings.Join(c.enum, ", ")))
+		}
+
+		if c.regex != nil && !c.regex.MatchString(value) {
+			out = append(out, fmt.Sprintf("'%s' must match '%s'", value, c.regex))
+		}
+	}
+
+	return out
+}
+
+func stringSliceContains(slice []string, value string) bool {
+	for _, s := range slice {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
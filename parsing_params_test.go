@@ -0,0 +1,184 @@
+package gimlet
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type paramsTestLevel string
+
+func (l *paramsTestLevel) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "low", "high":
+		*l = paramsTestLevel(text)
+		return nil
+	default:
+		return ErrorResponse{Message: "unknown level"}
+	}
+}
+
+type paramsTestPage struct {
+	Limit  int `query:"limit" default:"10" validate:"min=1,max=100"`
+	Offset int `query:"offset"`
+}
+
+type paramsTest struct {
+	paramsTestPage
+	ID      int             `path:"id" validate:"required,min=1"`
+	Name    string          `path:"name" validate:"regex=^[a-z]+(,[a-z]+)?$"`
+	Sort    string          `query:"sort" validate:"enum=asc|desc"`
+	Tags    []string        `query:"tag" validate:"max=2"`
+	Level   paramsTestLevel `query:"level"`
+	Since   *time.Time      `query:"since"`
+	Timeout time.Duration   `query:"timeout"`
+	Debug   bool            `query:"debug"`
+	Token   string          `header:"X-Token" validate:"required"`
+	Ignored string
+}
+
+func TestTypedParams(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/?n=4&f=1.5&ids=1&ids=2&bad=x", nil)
+	req.Header.Set("X-Count", "3")
+	req = SetURLVars(req, map[string]string{"id": "42"})
+
+	id, err := PathParam[int](req, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	n, err := QueryParam[uint8](req, "n")
+	require.NoError(t, err)
+	assert.Equal(t, uint8(4), n)
+
+	f, err := QueryParam[float64](req, "f")
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, f)
+
+	ids, err := QueryParam[[]int64](req, "ids")
+	require.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, ids)
+
+	count, err := HeaderParam[*int](req, "X-Count")
+	require.NoError(t, err)
+	require.NotNil(t, count)
+	assert.Equal(t, 3, *count)
+
+	_, err = QueryParam[int](req, "bad")
+	require.Error(t, err)
+	resp, ok := err.(ErrorResponse)
+	require.True(t, ok)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	require.Len(t, resp.Fields, 1)
+	assert.Equal(t, FieldError{Field: "bad", Source: ParamSourceQuery, Message: "'x' is not a valid integer"}, resp.Fields[0])
+
+	_, err = QueryParam[string](req, "missing")
+	assert.Error(t, err)
+
+	_, err = QueryParam[map[string]string](req, "n")
+	assert.Error(t, err)
+}
+
+func TestBindParams(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?sort=asc&tag=a&tag=b&level=high&since=2024-01-02T03:04:05Z&timeout=1m&debug=true&offset=5", nil)
+		req.Header.Set("X-Token", "secret")
+		req = SetURLVars(req, map[string]string{"id": "7", "name": "abc,def"})
+
+		out := paramsTest{Ignored: "value"}
+		require.NoError(t, BindParams(req, &out))
+		assert.Equal(t, 7, out.ID)
+		assert.Equal(t, "abc,def", out.Name)
+		assert.Equal(t, 10, out.Limit)
+		assert.Equal(t, 5, out.Offset)
+		assert.Equal(t, "asc", out.Sort)
+		assert.Equal(t, []string{"a", "b"}, out.Tags)
+		assert.Equal(t, paramsTestLevel("high"), out.Level)
+		require.NotNil(t, out.Since)
+		assert.Equal(t, 2024, out.Since.Year())
+		assert.Equal(t, time.Minute, out.Timeout)
+		assert.True(t, out.Debug)
+		assert.Equal(t, "secret", out.Token)
+		assert.Equal(t, "value", out.Ignored)
+	})
+	t.Run("Invalid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?limit=500&sort=up&tag=a&tag=b&tag=c&level=medium&timeout=soon", nil)
+		req = SetURLVars(req, map[string]string{"id": "0", "name": "ABC"})
+
+		err := BindParams(req, &paramsTest{})
+		require.Error(t, err)
+		resp, ok := err.(ErrorResponse)
+		require.True(t, ok)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+		failures := map[string]string{}
+		for _, f := range resp.Fields {
+			failures[f.Field] = f.Message
+		}
+		assert.Len(t, failures, 8)
+		assert.Equal(t, "must be at most 100", failures["limit"])
+		assert.Equal(t, "must be at least 1", failures["id"])
+		assert.Contains(t, failures["name"], "must match")
+		assert.Equal(t, "'up' must be one of [asc, desc]", failures["sort"])
+		assert.Equal(t, "length must be at most 2", failures["tag"])
+		assert.Contains(t, failures["level"], "unknown level")
+		assert.Contains(t, failures["timeout"], "not a valid duration")
+		assert.Equal(t, "is required", failures["X-Token"])
+
+		out, err := json.Marshal(resp)
+		require.NoError(t, err)
+		assert.Contains(t, string(out), `"fields":[`)
+		assert.True(t, strings.HasPrefix(resp.Message, "invalid parameters: "))
+	})
+	t.Run("Routers", func(t *testing.T) {
+		for name, impl := range map[string]RouterImplementation{
+			"Gorilla": RouterImplGorilla,
+			"Chi":     RouterImplChi,
+			"Stdlib":  RouterImplStdlib,
+		} {
+			t.Run(name, func(t *testing.T) {
+				app := NewApp()
+				app.SetRouter(impl)
+				app.AddRoute("/{name}/{id}").Version(1).Get().Handler(func(rw http.ResponseWriter, r *http.Request) {
+					out := paramsTest{}
+					if err := BindParams(r, &out); err != nil {
+						WriteResponse(rw, MakeJSONErrorResponder(err))
+						return
+					}
+					WriteJSON(rw, out)
+				})
+
+				h, err := app.Handler()
+				require.NoError(t, err)
+
+				rw := httptest.NewRecorder()
+				req := httptest.NewRequest(http.MethodGet, "/v1/abc/12", nil)
+				req.Header.Set("X-Token", "secret")
+				h.ServeHTTP(rw, req)
+				assert.Equal(t, http.StatusOK, rw.Code)
+				assert.Contains(t, rw.Body.String(), `"ID": 12`)
+
+				rw = httptest.NewRecorder()
+				h.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/abc/nope", nil))
+				assert.Equal(t, http.StatusBadRequest, rw.Code)
+				resp := ErrorResponse{}
+				require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &resp))
+				assert.Len(t, resp.Fields, 2)
+			})
+		}
+	})
+	t.Run("InvalidDestination", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		assert.Error(t, BindParams(req, paramsTest{}))
+		assert.Error(t, BindParams(req, nil))
+
+		assert.Error(t, BindParams(req, &struct {
+			Value int `query:"value" validate:"unknown"`
+		}{}))
+	})
+}